@@ -0,0 +1,50 @@
+package simpletrace
+
+import "math"
+
+// An affine transform, which maps (x, y) to (A*x + B*y + C, D*x + E*y + F)
+type Affine struct {
+	A, B, C float64
+	D, E, F float64
+}
+
+var IdentityAffine = Affine{A: 1, E: 1}
+
+func TranslationAffine(dx, dy float64) Affine {
+	return Affine{A: 1, C: dx, E: 1, F: dy}
+}
+
+func ScaleAffine(sx, sy float64) Affine {
+	return Affine{A: sx, E: sy}
+}
+
+// Rotate by angle radians, from the positive x axis toward the positive y axis
+func RotationAffine(angle float64) Affine {
+	sin, cos := math.Sincos(angle)
+	return Affine{A: cos, B: -sin, D: sin, E: cos}
+}
+
+func (m Affine) Apply(p Point) Point {
+	return Point{
+		m.A*p.X + m.B*p.Y + m.C,
+		m.D*p.X + m.E*p.Y + m.F,
+	}
+}
+
+// The transform which applies m, and then next
+func (m Affine) Then(next Affine) Affine {
+	return Affine{
+		A: next.A*m.A + next.B*m.D,
+		B: next.A*m.B + next.B*m.E,
+		C: next.A*m.C + next.B*m.F + next.C,
+		D: next.D*m.A + next.E*m.D,
+		E: next.D*m.B + next.E*m.E,
+		F: next.D*m.C + next.E*m.F + next.F,
+	}
+}
+
+// The factor by which the transform scales areas. It is negative if the
+// transform is a reflection, which reverses the winding of polygons.
+func (m Affine) Determinant() float64 {
+	return m.A*m.E - m.B*m.D
+}
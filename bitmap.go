@@ -0,0 +1,79 @@
+package simpletrace
+
+import (
+	"image"
+	"image/color"
+)
+
+// A two-state image, where each pixel is either filled or empty. This is what
+// an image becomes after its colors are classified by an IsColorFilledFunc.
+//
+// Bitmap implements image.Image, with filled pixels opaque and empty pixels
+// transparent, so it can be traced directly with OpacityColorFilledFunc.
+type Bitmap struct {
+	Rect image.Rectangle
+	Pix  []bool
+}
+
+func NewBitmap(r image.Rectangle) *Bitmap {
+	return &Bitmap{
+		Rect: r,
+		Pix:  make([]bool, r.Dx()*r.Dy()),
+	}
+}
+
+// Classify every pixel of an image as filled or empty
+func BitmapFromImage(img image.Image, isColorFilled IsColorFilledFunc) *Bitmap {
+	bounds := img.Bounds()
+	bitmap := NewBitmap(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			bitmap.Pix[bitmap.offset(x, y)] = isColorFilled(img.At(x, y))
+		}
+	}
+	return bitmap
+}
+
+func (b *Bitmap) offset(x, y int) int {
+	return (y-b.Rect.Min.Y)*b.Rect.Dx() + (x - b.Rect.Min.X)
+}
+
+// Whether the pixel is filled. Pixels outside the bitmap are always empty.
+func (b *Bitmap) Filled(x, y int) bool {
+	if !(image.Point{x, y}).In(b.Rect) {
+		return false
+	}
+	return b.Pix[b.offset(x, y)]
+}
+
+// Set whether the pixel is filled. Pixels outside the bitmap are ignored.
+func (b *Bitmap) SetFilled(x, y int, filled bool) {
+	if !(image.Point{x, y}).In(b.Rect) {
+		return
+	}
+	b.Pix[b.offset(x, y)] = filled
+}
+
+func (b *Bitmap) Clone() *Bitmap {
+	clone := &Bitmap{
+		Rect: b.Rect,
+		Pix:  make([]bool, len(b.Pix)),
+	}
+	copy(clone.Pix, b.Pix)
+	return clone
+}
+
+func (b *Bitmap) ColorModel() color.Model {
+	return color.AlphaModel
+}
+
+func (b *Bitmap) Bounds() image.Rectangle {
+	return b.Rect
+}
+
+func (b *Bitmap) At(x, y int) color.Color {
+	if b.Filled(x, y) {
+		return color.Opaque
+	}
+	return color.Transparent
+}
@@ -0,0 +1,409 @@
+package simpletrace
+
+import (
+	"math"
+	"sort"
+)
+
+// The union of two trace results, covering everything filled in either one.
+//
+// All of the boolean operations take polygons in the default winding
+// convention, with filled polygons counterclockwise and holes clockwise, and
+// return polygons in the same convention. The inputs may overlap themselves; a
+// point is filled if the polygons around it wind counterclockwise more often
+// than clockwise. The methods of WindingConvention with the same names work in
+// other conventions.
+func Union(a, b []Polygon) []Polygon {
+	return FilledCounterclockwise.Union(a, b)
+}
+
+// The intersection of two trace results, covering everything filled in both
+func Intersection(a, b []Polygon) []Polygon {
+	return FilledCounterclockwise.Intersection(a, b)
+}
+
+// The difference of two trace results, covering everything filled in a but not
+// in b
+func Difference(a, b []Polygon) []Polygon {
+	return FilledCounterclockwise.Difference(a, b)
+}
+
+// The symmetric difference of two trace results, covering everything filled in
+// exactly one of them
+func Xor(a, b []Polygon) []Polygon {
+	return FilledCounterclockwise.Xor(a, b)
+}
+
+// Union for polygons in this winding convention
+func (c WindingConvention) Union(a, b []Polygon) []Polygon {
+	return c.booleanOperation(a, b, func(inA, inB bool) bool { return inA || inB })
+}
+
+// Intersection for polygons in this winding convention
+func (c WindingConvention) Intersection(a, b []Polygon) []Polygon {
+	return c.booleanOperation(a, b, func(inA, inB bool) bool { return inA && inB })
+}
+
+// Difference for polygons in this winding convention
+func (c WindingConvention) Difference(a, b []Polygon) []Polygon {
+	return c.booleanOperation(a, b, func(inA, inB bool) bool { return inA && !inB })
+}
+
+// Xor for polygons in this winding convention
+func (c WindingConvention) Xor(a, b []Polygon) []Polygon {
+	return c.booleanOperation(a, b, func(inA, inB bool) bool { return inA != inB })
+}
+
+func (c WindingConvention) booleanOperation(a, b []Polygon, operation func(inA, inB bool) bool) []Polygon {
+	return c.Convert(overlay(c.Convert(a), c.Convert(b), positiveWinding, operation))
+}
+
+// Decides whether a point is filled from the winding number of the polygons
+// around it
+type fillRule func(winding int) bool
+
+func positiveWinding(winding int) bool {
+	return winding > 0
+}
+
+func nonZeroWinding(winding int) bool {
+	return winding != 0
+}
+
+// Points closer together than this are treated as the same vertex
+const overlayTolerance = 1e-7
+
+// An edge of an input polygon, and the points where it has to be split because
+// other edges cross or touch it
+type overlayEdge struct {
+	from, to Point
+	operand  int
+	splits   []overlaySplit
+}
+
+type overlaySplit struct {
+	t      float64 // Position along the edge, from 0 at the start to 1 at the end
+	vertex int
+}
+
+// A piece of an input edge, with no other edges crossing it. Identical pieces
+// from different edges are combined, and each operand's winding tells how many
+// more times it runs from a to b than from b to a.
+type overlaySegment struct {
+	a, b    int
+	winding [2]int
+}
+
+// Compute a boolean operation by splitting every edge wherever it meets another
+// edge, working out which operands are filled on either side of each piece, and
+// keeping the pieces where the result is filled on one side and not the other.
+func overlay(a, b []Polygon, rule fillRule, operation func(inA, inB bool) bool) []Polygon {
+	var vertices overlayVertices
+	var edges []*overlayEdge
+	for operand, polygons := range [2][]Polygon{a, b} {
+		for _, polygon := range polygons {
+			for i := range polygon {
+				from, to := polygon[i], polygon[(i+1)%len(polygon)]
+				if from.DistanceTo(to) <= overlayTolerance {
+					continue
+				}
+				edge := &overlayEdge{from: from, to: to, operand: operand}
+				edge.splits = []overlaySplit{{0, vertices.find(from)}, {1, vertices.find(to)}}
+				edges = append(edges, edge)
+			}
+		}
+	}
+
+	splitIntersectingEdges(edges, &vertices)
+	segments := combineSegments(edges)
+
+	// Keep each segment that separates the filled part of the result from the
+	// unfilled part, pointing so that the filled side is on its left.
+	var boundary [][2]int
+	for _, segment := range segments {
+		left, right := segmentWindings(segment, segments, vertices.points)
+		insideLeft := operation(rule(left[0]), rule(left[1]))
+		insideRight := operation(rule(right[0]), rule(right[1]))
+		if insideLeft && !insideRight {
+			boundary = append(boundary, [2]int{segment.a, segment.b})
+		} else if insideRight && !insideLeft {
+			boundary = append(boundary, [2]int{segment.b, segment.a})
+		}
+	}
+
+	return linkBoundary(boundary, vertices.points)
+}
+
+// Split every edge at the points where other edges cross or touch it
+func splitIntersectingEdges(edges []*overlayEdge, vertices *overlayVertices) {
+	// Sweep from left to right, so that only edges with overlapping x ranges are
+	// compared
+	sorted := make([]*overlayEdge, len(edges))
+	copy(sorted, edges)
+	minX := func(e *overlayEdge) float64 { return math.Min(e.from.X, e.to.X) }
+	maxX := func(e *overlayEdge) float64 { return math.Max(e.from.X, e.to.X) }
+	sort.Slice(sorted, func(i, j int) bool { return minX(sorted[i]) < minX(sorted[j]) })
+
+	for i, e := range sorted {
+		for _, f := range sorted[i+1:] {
+			if minX(f) > maxX(e)+overlayTolerance {
+				break
+			}
+			if math.Min(f.from.Y, f.to.Y) > math.Max(e.from.Y, e.to.Y)+overlayTolerance ||
+				math.Min(e.from.Y, e.to.Y) > math.Max(f.from.Y, f.to.Y)+overlayTolerance {
+				continue
+			}
+			for _, p := range edgeIntersections(e.from, e.to, f.from, f.to) {
+				vertex := vertices.find(p)
+				e.splits = append(e.splits, overlaySplit{parameterAlong(e.from, e.to, p), vertex})
+				f.splits = append(f.splits, overlaySplit{parameterAlong(f.from, f.to, p), vertex})
+			}
+		}
+	}
+}
+
+// The points where the segments ab and cd meet. This is a single point if they
+// cross or touch, and the ends of the overlap if they are collinear.
+func edgeIntersections(a, b, c, d Point) []Point {
+	r := Point{b.X - a.X, b.Y - a.Y}
+	s := Point{d.X - c.X, d.Y - c.Y}
+	denominator := r.X*s.Y - r.Y*s.X
+	ac := Point{c.X - a.X, c.Y - a.Y}
+	lengthR := math.Hypot(r.X, r.Y)
+	lengthS := math.Hypot(s.X, s.Y)
+
+	// How far, in units of distance, each point is allowed to be off an edge
+	// while still counting as on it
+	tolerance := overlayTolerance
+
+	if math.Abs(denominator) > 1e-12*lengthR*lengthS {
+		t := (ac.X*s.Y - ac.Y*s.X) / denominator
+		u := (ac.X*r.Y - ac.Y*r.X) / denominator
+		if t < -tolerance/lengthR || t > 1+tolerance/lengthR || u < -tolerance/lengthS || u > 1+tolerance/lengthS {
+			return nil
+		}
+		t = math.Max(0, math.Min(1, t))
+		return []Point{{a.X + r.X*t, a.Y + r.Y*t}}
+	}
+
+	// Parallel edges only meet if they are on the same line
+	if math.Abs(ac.X*r.Y-ac.Y*r.X)/lengthR > tolerance {
+		return nil
+	}
+	var points []Point
+	for _, p := range []Point{c, d} {
+		if t := parameterAlong(a, b, p); t > 0 && t < 1 {
+			points = append(points, p)
+		}
+	}
+	for _, p := range []Point{a, b} {
+		if t := parameterAlong(c, d, p); t > 0 && t < 1 {
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+// The position of p projected onto the line through a and b, where a is 0 and b
+// is 1
+func parameterAlong(a, b, p Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	return ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / (dx*dx + dy*dy)
+}
+
+// Cut the edges into segments at their splits, and combine identical segments
+func combineSegments(edges []*overlayEdge) []*overlaySegment {
+	var segments []*overlaySegment
+	byEnds := make(map[[2]int]*overlaySegment)
+	for _, edge := range edges {
+		sort.Slice(edge.splits, func(i, j int) bool { return edge.splits[i].t < edge.splits[j].t })
+		for i := 1; i < len(edge.splits); i++ {
+			from, to := edge.splits[i-1].vertex, edge.splits[i].vertex
+			if from == to {
+				continue
+			}
+			key := edgeKey(from, to)
+			segment := byEnds[key]
+			if segment == nil {
+				segment = &overlaySegment{a: key[0], b: key[1]}
+				byEnds[key] = segment
+				segments = append(segments, segment)
+			}
+			if from == segment.a {
+				segment.winding[edge.operand]++
+			} else {
+				segment.winding[edge.operand]--
+			}
+		}
+	}
+	return segments
+}
+
+// Find the winding number of each operand just to the left and just to the
+// right of a segment, by casting a ray from its midpoint and counting the
+// segments it crosses.
+func segmentWindings(segment *overlaySegment, segments []*overlaySegment, points []Point) (left [2]int, right [2]int) {
+	a, b := points[segment.a], points[segment.b]
+	mid := Point{(a.X + b.X) / 2, (a.Y + b.Y) / 2}
+	dx, dy := b.X-a.X, b.Y-a.Y
+
+	// Cast the ray across the segment, rather than along it. A ray toward +x
+	// starts out on the left of the segment when the segment runs toward -y,
+	// and a ray toward +y starts out on the left when the segment runs
+	// toward +x.
+	horizontal := math.Abs(dy) > math.Abs(dx)
+	var rayOnLeft bool
+	if horizontal {
+		rayOnLeft = dy < 0
+	} else {
+		rayOnLeft = dx > 0
+	}
+
+	var winding [2]int
+	for _, other := range segments {
+		if other == segment {
+			continue
+		}
+		p, q := points[other.a], points[other.b]
+		direction := 0
+		if horizontal {
+			// Crossing upward adds to the winding, because that's how the ray
+			// leaves a counterclockwise polygon
+			if p.Y <= mid.Y && mid.Y < q.Y {
+				direction = 1
+			} else if q.Y <= mid.Y && mid.Y < p.Y {
+				direction = -1
+			}
+			if direction == 0 || p.X+(mid.Y-p.Y)*(q.X-p.X)/(q.Y-p.Y) <= mid.X {
+				continue
+			}
+		} else {
+			// Leaving a counterclockwise polygon upward crosses its top edge,
+			// which runs toward -x
+			if q.X <= mid.X && mid.X < p.X {
+				direction = 1
+			} else if p.X <= mid.X && mid.X < q.X {
+				direction = -1
+			}
+			if direction == 0 || p.Y+(mid.X-p.X)*(q.Y-p.Y)/(q.X-p.X) <= mid.Y {
+				continue
+			}
+		}
+		winding[0] += direction * other.winding[0]
+		winding[1] += direction * other.winding[1]
+	}
+
+	// Crossing a counterclockwise edge from right to left moves inside it
+	for operand := range winding {
+		if rayOnLeft {
+			left[operand] = winding[operand]
+			right[operand] = winding[operand] - segment.winding[operand]
+		} else {
+			right[operand] = winding[operand]
+			left[operand] = winding[operand] + segment.winding[operand]
+		}
+	}
+	return left, right
+}
+
+// Join directed boundary edges into closed polygons. Where several edges leave
+// the same vertex, take the sharpest left turn, so that polygons which touch at
+// a vertex come out as separate polygons rather than crossing each other.
+func linkBoundary(boundary [][2]int, points []Point) []Polygon {
+	outgoing := make(map[int][]int)
+	for i, edge := range boundary {
+		outgoing[edge[0]] = append(outgoing[edge[0]], i)
+	}
+	used := make([]bool, len(boundary))
+
+	var polygons []Polygon
+	for start := range boundary {
+		if used[start] {
+			continue
+		}
+		var ring []int
+		current := start
+		for !used[current] {
+			used[current] = true
+			from, to := boundary[current][0], boundary[current][1]
+			ring = append(ring, from)
+
+			next := -1
+			bestAngle := math.Inf(-1)
+			incoming := Point{points[to].X - points[from].X, points[to].Y - points[from].Y}
+			for _, candidate := range outgoing[to] {
+				if used[candidate] {
+					continue
+				}
+				target := points[boundary[candidate][1]]
+				out := Point{target.X - points[to].X, target.Y - points[to].Y}
+				angle := math.Atan2(incoming.X*out.Y-incoming.Y*out.X, incoming.X*out.X+incoming.Y*out.Y)
+				if angle > bestAngle {
+					bestAngle = angle
+					next = candidate
+				}
+			}
+			if next < 0 {
+				break
+			}
+			current = next
+		}
+
+		polygon := make(Polygon, len(ring))
+		for i, vertex := range ring {
+			polygon[i] = points[vertex]
+		}
+		polygon = removeCollinearVertices(polygon)
+		if len(polygon) >= 3 && polygon.SignedArea() != 0 {
+			polygons = append(polygons, polygon)
+		}
+	}
+	return polygons
+}
+
+// Remove vertices which lie on the straight line between their neighbors
+func removeCollinearVertices(polygon Polygon) Polygon {
+	for changed := true; changed && len(polygon) >= 3; {
+		changed = false
+		for i := 0; i < len(polygon) && len(polygon) >= 3; i++ {
+			n := len(polygon)
+			a, b, c := polygon[(i+n-1)%n], polygon[i], polygon[(i+1)%n]
+			length := a.DistanceTo(c)
+			if math.Abs(cross(a, b, c)) <= overlayTolerance*length || a.DistanceTo(b) <= overlayTolerance {
+				polygon = append(polygon[:i:i], polygon[i+1:]...)
+				changed = true
+				i--
+			}
+		}
+	}
+	return polygon
+}
+
+// The vertices of an overlay, where points closer than overlayTolerance are
+// merged into one
+type overlayVertices struct {
+	points []Point
+	cells  map[[2]int64][]int
+}
+
+// Get the index of the vertex at p, adding it if there is none
+func (v *overlayVertices) find(p Point) int {
+	if v.cells == nil {
+		v.cells = make(map[[2]int64][]int)
+	}
+	cellX := int64(math.Floor(p.X / overlayTolerance))
+	cellY := int64(math.Floor(p.Y / overlayTolerance))
+	for y := cellY - 1; y <= cellY+1; y++ {
+		for x := cellX - 1; x <= cellX+1; x++ {
+			for _, i := range v.cells[[2]int64{x, y}] {
+				if v.points[i].DistanceTo(p) <= overlayTolerance {
+					return i
+				}
+			}
+		}
+	}
+	v.points = append(v.points, p)
+	key := [2]int64{cellX, cellY}
+	v.cells[key] = append(v.cells[key], len(v.points)-1)
+	return len(v.points) - 1
+}
@@ -0,0 +1,83 @@
+package simpletrace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func totalSignedArea(polygons []Polygon) float64 {
+	area := 0.0
+	for _, polygon := range polygons {
+		area += polygon.SignedArea()
+	}
+	return area
+}
+
+// A counterclockwise square with its top left corner at (x, y)
+func squarePolygon(x, y, size float64) Polygon {
+	return Polygon{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}}
+}
+
+func TestBooleanOperations(t *testing.T) {
+	a := []Polygon{squarePolygon(0, 0, 2)}
+	b := []Polygon{squarePolygon(1, 1, 2)}
+
+	assert.InDelta(t, 7, totalSignedArea(Union(a, b)), 1e-9)
+	assert.InDelta(t, 1, totalSignedArea(Intersection(a, b)), 1e-9)
+	assert.InDelta(t, 3, totalSignedArea(Difference(a, b)), 1e-9)
+	assert.InDelta(t, 6, totalSignedArea(Xor(a, b)), 1e-9)
+	assert.Len(t, Union(a, b), 1)
+	assert.Len(t, Xor(a, b), 2)
+
+	// Shared edges merge cleanly, leaving no collinear vertices behind
+	union := Union(a, []Polygon{squarePolygon(2, 0, 2)})
+	assert.Len(t, union, 1)
+	assert.Len(t, union[0], 4)
+	assert.InDelta(t, 8, union[0].SignedArea(), 1e-9)
+
+	// Cutting a hole leaves a filled polygon and a hole in the trace convention
+	withHole := Difference([]Polygon{squarePolygon(0, 0, 4)}, []Polygon{squarePolygon(1, 1, 2)})
+	assert.Len(t, withHole, 2)
+	filled, holes := countWindings(withHole)
+	assert.Equal(t, 1, filled)
+	assert.Equal(t, 1, holes)
+	assert.InDelta(t, 12, totalSignedArea(withHole), 1e-9)
+
+	// Holes in the operands are respected
+	assert.InDelta(t, 0, totalSignedArea(Intersection(withHole, []Polygon{squarePolygon(1.5, 1.5, 1)})), 1e-9)
+	assert.InDelta(t, 16, totalSignedArea(Union(withHole, []Polygon{squarePolygon(1, 1, 2)})), 1e-9)
+
+	// Squares touching at a corner stay separate
+	touching := Union(a, []Polygon{squarePolygon(2, 2, 2)})
+	assert.Len(t, touching, 2)
+	assert.InDelta(t, 8, totalSignedArea(touching), 1e-9)
+}
+
+func TestBooleanOperationsOnTraces(t *testing.T) {
+	left := TraceImage(imageFromRows(
+		"..........",
+		".XXXXXX...",
+		".X....X...",
+		".X....X...",
+		".XXXXXX...",
+		"..........",
+	), OpacityColorFilledFunc)
+	right := TraceImage(imageFromRows(
+		"..........",
+		"...XXXXXX.",
+		"...XXXXXX.",
+		"...XXXXXX.",
+		"...XXXXXX.",
+		"..........",
+	), OpacityColorFilledFunc)
+
+	union := Union(left, right)
+	intersection := Intersection(left, right)
+	// Inclusion-exclusion holds exactly for any pair of regions
+	assert.InDelta(t, totalSignedArea(left)+totalSignedArea(right), totalSignedArea(union)+totalSignedArea(intersection), 1e-6)
+	assert.InDelta(t, totalSignedArea(left)-totalSignedArea(intersection), totalSignedArea(Difference(left, right)), 1e-6)
+	assert.InDelta(t, totalSignedArea(union)-totalSignedArea(intersection), totalSignedArea(Xor(left, right)), 1e-6)
+	// Union with itself changes nothing
+	assert.InDelta(t, totalSignedArea(left), totalSignedArea(Union(left, left)), 1e-6)
+}
@@ -0,0 +1,338 @@
+package simpletrace
+
+import (
+	"image"
+	"math"
+)
+
+type CenterlineOptions struct {
+	// Operations applied to the bitmap before it is skeletonized, as in
+	// TraceOptions
+	Preprocess []BitmapFilter
+	// Branches running from a loose end to a junction which are shorter than
+	// this, in pixels, are removed. Thinning leaves short spurs wherever an
+	// outline has a bump, so this is worth setting to around the stroke width.
+	MinBranchLength float64
+	// How far simplified strokes may stray from the skeleton, in pixels. With
+	// the default of zero, strokes have a vertex at every skeleton pixel.
+	Tolerance float64
+}
+
+// Whether a StrokeNode is the loose end of a stroke or a place where strokes
+// meet
+type StrokeNodeKind uint8
+
+const (
+	StrokeEndpoint = StrokeNodeKind(iota)
+	StrokeJunction
+)
+
+// A point where strokes end
+type StrokeNode struct {
+	Kind  StrokeNodeKind
+	Point Point
+	// The indices of the strokes which start or end here. A stroke that starts
+	// and ends at the same node is listed twice.
+	Strokes []int
+}
+
+// An open polyline along the middle of a stroke
+type Stroke struct {
+	Points []Point
+	// The full width of the stroke at each point
+	Widths []float64
+	// The nodes at either end of the stroke, or -1 for closed loops, which have
+	// no ends. The first and last points of a closed loop are the same.
+	Start, End int
+}
+
+// The centerlines of a bitmap, as a graph of strokes which meet at nodes
+type Centerlines struct {
+	Strokes []Stroke
+	Nodes   []StrokeNode
+}
+
+// Trace the centerlines of the filled regions of an image, rather than their
+// outlines. This is meant for line art, where each pen stroke should become a
+// single line for a plotter or CAD program instead of an outline on either
+// side of it.
+//
+// Points are in the same coordinates as TraceImage, with pixel centers at
+// integer coordinates.
+func TraceCenterlines(img image.Image, isColorFilledFunc IsColorFilledFunc, options CenterlineOptions) Centerlines {
+	bitmap := BitmapFromImage(img, isColorFilledFunc)
+	for _, filter := range options.Preprocess {
+		bitmap = filter(bitmap)
+	}
+
+	skeleton := bitmap.Skeletonize()
+	if options.MinBranchLength > 0 {
+		pruneBranches(skeleton, options.MinBranchLength)
+	}
+
+	distances := bitmap.DistanceTransform()
+	widthAt := func(p image.Point) float64 {
+		// The outline runs halfway between filled and empty pixel centers
+		return math.Max(2*distances[bitmap.offset(p.X, p.Y)]-1, 1)
+	}
+
+	centerlines := buildStrokeGraph(skeleton, widthAt)
+	if options.Tolerance > 0 {
+		for i, stroke := range centerlines.Strokes {
+			centerlines.Strokes[i] = simplifyStroke(stroke, options.Tolerance)
+		}
+	}
+	return centerlines
+}
+
+func skeletonNeighbors(skeleton *Bitmap, p image.Point) []image.Point {
+	var neighbors []image.Point
+	for _, offset := range neighborOffsets {
+		q := p.Add(offset)
+		if skeleton.Filled(q.X, q.Y) {
+			neighbors = append(neighbors, q)
+		}
+	}
+	return neighbors
+}
+
+// Remove branches running from an endpoint to a junction which are shorter
+// than minLength
+func pruneBranches(skeleton *Bitmap, minLength float64) {
+	var endpoints []image.Point
+	for y := skeleton.Rect.Min.Y; y < skeleton.Rect.Max.Y; y++ {
+		for x := skeleton.Rect.Min.X; x < skeleton.Rect.Max.X; x++ {
+			p := image.Point{x, y}
+			if skeleton.Filled(x, y) && len(skeletonNeighbors(skeleton, p)) == 1 {
+				endpoints = append(endpoints, p)
+			}
+		}
+	}
+
+	for _, end := range endpoints {
+		branch := []image.Point{end}
+		length := 0.0
+		previous, current := end, end
+		for {
+			var next []image.Point
+			for _, q := range skeletonNeighbors(skeleton, current) {
+				if q != previous {
+					next = append(next, q)
+				}
+			}
+			if len(next) != 1 {
+				// A junction, or the other end of an isolated line, which is left
+				// alone
+				if len(next) > 1 && length < minLength {
+					for _, p := range branch[:len(branch)-1] {
+						skeleton.SetFilled(p.X, p.Y, false)
+					}
+				}
+				break
+			}
+			length += math.Hypot(float64(next[0].X-current.X), float64(next[0].Y-current.Y))
+			previous, current = current, next[0]
+			branch = append(branch, current)
+			if length >= minLength {
+				break
+			}
+		}
+	}
+}
+
+// Turn a skeleton into strokes between nodes. Skeleton pixels with one
+// neighbor are endpoints, and clusters of pixels with three or more neighbors
+// are junctions.
+func buildStrokeGraph(skeleton *Bitmap, widthAt func(image.Point) float64) Centerlines {
+	var centerlines Centerlines
+	nodeAt := make(map[image.Point]int)
+
+	degree := func(p image.Point) int { return len(skeletonNeighbors(skeleton, p)) }
+	isNodePixel := func(p image.Point) bool {
+		d := degree(p)
+		return d != 2
+	}
+
+	// Gather node pixels into nodes, flooding through touching junction pixels
+	for y := skeleton.Rect.Min.Y; y < skeleton.Rect.Max.Y; y++ {
+		for x := skeleton.Rect.Min.X; x < skeleton.Rect.Max.X; x++ {
+			p := image.Point{x, y}
+			if !skeleton.Filled(x, y) || !isNodePixel(p) {
+				continue
+			}
+			if _, ok := nodeAt[p]; ok {
+				continue
+			}
+			index := len(centerlines.Nodes)
+			kind := StrokeJunction
+			if degree(p) <= 1 {
+				kind = StrokeEndpoint
+			}
+			cluster := []image.Point{p}
+			nodeAt[p] = index
+			for i := 0; i < len(cluster) && kind == StrokeJunction; i++ {
+				for _, q := range skeletonNeighbors(skeleton, cluster[i]) {
+					if _, ok := nodeAt[q]; !ok && degree(q) > 2 {
+						nodeAt[q] = index
+						cluster = append(cluster, q)
+					}
+				}
+			}
+			var sum Point
+			for _, q := range cluster {
+				sum.X += float64(q.X)
+				sum.Y += float64(q.Y)
+			}
+			centerlines.Nodes = append(centerlines.Nodes, StrokeNode{
+				Kind:  kind,
+				Point: Point{sum.X / float64(len(cluster)), sum.Y / float64(len(cluster))},
+			})
+		}
+	}
+
+	// Each step between neighboring pixels is walked once
+	type step struct{ from, to image.Point }
+	walked := make(map[step]bool)
+	addStroke := func(stroke Stroke) {
+		index := len(centerlines.Strokes)
+		centerlines.Strokes = append(centerlines.Strokes, stroke)
+		if stroke.Start >= 0 {
+			centerlines.Nodes[stroke.Start].Strokes = append(centerlines.Nodes[stroke.Start].Strokes, index)
+			centerlines.Nodes[stroke.End].Strokes = append(centerlines.Nodes[stroke.End].Strokes, index)
+		}
+	}
+	pointOf := func(p image.Point) Point { return PointFromInts(p.X, p.Y) }
+
+	// Walk from a pixel in the given direction until reaching a node, or coming
+	// back around to the start
+	walk := func(start, first image.Point) (pixels []image.Point, end image.Point) {
+		pixels = []image.Point{start}
+		previous, current := start, first
+		for {
+			walked[step{previous, current}] = true
+			walked[step{current, previous}] = true
+			pixels = append(pixels, current)
+			if _, ok := nodeAt[current]; ok || current == start {
+				return pixels, current
+			}
+			var next image.Point
+			for _, q := range skeletonNeighbors(skeleton, current) {
+				if q != previous {
+					next = q
+					break
+				}
+			}
+			previous, current = current, next
+		}
+	}
+
+	for y := skeleton.Rect.Min.Y; y < skeleton.Rect.Max.Y; y++ {
+		for x := skeleton.Rect.Min.X; x < skeleton.Rect.Max.X; x++ {
+			p := image.Point{x, y}
+			node, ok := nodeAt[p]
+			if !ok {
+				continue
+			}
+			if degree(p) == 0 {
+				// A lone dot
+				addStroke(Stroke{
+					Points: []Point{pointOf(p)},
+					Widths: []float64{widthAt(p)},
+					Start:  node,
+					End:    node,
+				})
+				continue
+			}
+			for _, q := range skeletonNeighbors(skeleton, p) {
+				if walked[step{p, q}] || nodeAt[q] == node && isNodePixel(q) {
+					continue
+				}
+				pixels, end := walk(p, q)
+				endNode := nodeAt[end]
+				// Strokes run between node centers rather than the pixels where
+				// they happen to meet the node
+				stroke := Stroke{Start: node, End: endNode}
+				stroke.Points = append(stroke.Points, centerlines.Nodes[node].Point)
+				stroke.Widths = append(stroke.Widths, widthAt(p))
+				for _, pixel := range pixels[1 : len(pixels)-1] {
+					stroke.Points = append(stroke.Points, pointOf(pixel))
+					stroke.Widths = append(stroke.Widths, widthAt(pixel))
+				}
+				stroke.Points = append(stroke.Points, centerlines.Nodes[endNode].Point)
+				stroke.Widths = append(stroke.Widths, widthAt(end))
+				addStroke(stroke)
+			}
+		}
+	}
+
+	// Anything left is a closed loop with no nodes on it
+	for y := skeleton.Rect.Min.Y; y < skeleton.Rect.Max.Y; y++ {
+		for x := skeleton.Rect.Min.X; x < skeleton.Rect.Max.X; x++ {
+			p := image.Point{x, y}
+			if !skeleton.Filled(x, y) || isNodePixel(p) {
+				continue
+			}
+			neighbors := skeletonNeighbors(skeleton, p)
+			if walked[step{p, neighbors[0]}] || walked[step{p, neighbors[1]}] {
+				continue
+			}
+			pixels, _ := walk(p, neighbors[0])
+			stroke := Stroke{Start: -1, End: -1}
+			for _, pixel := range pixels {
+				stroke.Points = append(stroke.Points, pointOf(pixel))
+				stroke.Widths = append(stroke.Widths, widthAt(pixel))
+			}
+			addStroke(stroke)
+		}
+	}
+	return centerlines
+}
+
+// Simplify a stroke with the Douglas-Peucker algorithm, keeping its ends
+func simplifyStroke(stroke Stroke, tolerance float64) Stroke {
+	if len(stroke.Points) < 3 {
+		return stroke
+	}
+	keep := make([]bool, len(stroke.Points))
+	keep[0], keep[len(keep)-1] = true, true
+	var simplify func(first, last int)
+	simplify = func(first, last int) {
+		farthest, farthestDistance := -1, tolerance
+		for i := first + 1; i < last; i++ {
+			distance := distanceToSegment(stroke.Points[i], stroke.Points[first], stroke.Points[last])
+			if distance > farthestDistance {
+				farthest, farthestDistance = i, distance
+			}
+		}
+		if farthest < 0 {
+			return
+		}
+		keep[farthest] = true
+		simplify(first, farthest)
+		simplify(farthest, last)
+	}
+	if stroke.Start < 0 {
+		// A closed loop starts and ends at the same point, so split it at the
+		// point farthest from there first
+		farthest := 1
+		for i := range stroke.Points {
+			if stroke.Points[i].DistanceTo(stroke.Points[0]) > stroke.Points[farthest].DistanceTo(stroke.Points[0]) {
+				farthest = i
+			}
+		}
+		keep[farthest] = true
+		simplify(0, farthest)
+		simplify(farthest, len(keep)-1)
+	} else {
+		simplify(0, len(keep)-1)
+	}
+
+	simplified := Stroke{Start: stroke.Start, End: stroke.End}
+	for i, kept := range keep {
+		if kept {
+			simplified.Points = append(simplified.Points, stroke.Points[i])
+			simplified.Widths = append(simplified.Widths, stroke.Widths[i])
+		}
+	}
+	return simplified
+}
@@ -0,0 +1,119 @@
+package simpletrace
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func countFilled(bitmap *Bitmap) int {
+	count := 0
+	for _, filled := range bitmap.Pix {
+		if filled {
+			count++
+		}
+	}
+	return count
+}
+
+func TestDistanceTransform(t *testing.T) {
+	bitmap := NewBitmap(image.Rect(0, 0, 7, 5))
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 7; x++ {
+			bitmap.SetFilled(x, y, y > 0 && y < 4 && x > 0 && x < 6)
+		}
+	}
+	distances := bitmap.DistanceTransform()
+	assert.Equal(t, 0.0, distances[bitmap.offset(0, 0)])
+	assert.Equal(t, 1.0, distances[bitmap.offset(1, 2)])
+	assert.Equal(t, 2.0, distances[bitmap.offset(3, 2)])
+
+	// Outside the bitmap counts as empty
+	full := NewBitmap(image.Rect(0, 0, 3, 3))
+	for i := range full.Pix {
+		full.Pix[i] = true
+	}
+	distances = full.DistanceTransform()
+	assert.Equal(t, 2.0, distances[full.offset(1, 1)])
+	assert.Equal(t, 1.0, distances[full.offset(0, 0)])
+}
+
+func TestSkeletonize(t *testing.T) {
+	bitmap := BitmapFromImage(imageFromRows(
+		"............",
+		".XXXXXXXXXX.",
+		".XXXXXXXXXX.",
+		".XXXXXXXXXX.",
+		"............",
+	), OpacityColorFilledFunc)
+	skeleton := bitmap.Skeletonize()
+	count := countFilled(skeleton)
+	assert.Greater(t, count, 5)
+	assert.LessOrEqual(t, count, 10)
+	for x := 0; x < 12; x++ {
+		assert.False(t, skeleton.Filled(x, 1) && skeleton.Filled(x, 3))
+	}
+
+	// A small block doesn't vanish
+	block := BitmapFromImage(imageFromRows("XX", "XX"), OpacityColorFilledFunc)
+	assert.Greater(t, countFilled(block.Skeletonize()), 0)
+}
+
+func TestTraceCenterlines(t *testing.T) {
+	// A thick plus sign
+	rows := make([]string, 25)
+	for y := range rows {
+		row := []byte("                         ")
+		for x := range row {
+			if (x >= 11 && x <= 13 && y >= 2 && y <= 22) || (y >= 11 && y <= 13 && x >= 2 && x <= 22) {
+				row[x] = 'X'
+			}
+		}
+		rows[y] = string(row)
+	}
+	centerlines := TraceCenterlines(imageFromRows(rows...), OpacityColorFilledFunc, CenterlineOptions{
+		MinBranchLength: 3,
+		Tolerance:       0.5,
+	})
+
+	endpoints, junctions := 0, 0
+	for _, node := range centerlines.Nodes {
+		if node.Kind == StrokeJunction {
+			junctions++
+			assert.InDelta(t, 12, node.Point.X, 1)
+			assert.InDelta(t, 12, node.Point.Y, 1)
+			assert.Len(t, node.Strokes, 4)
+		} else {
+			endpoints++
+			assert.Len(t, node.Strokes, 1)
+		}
+	}
+	assert.Equal(t, 1, junctions)
+	assert.Equal(t, 4, endpoints)
+	assert.Len(t, centerlines.Strokes, 4)
+	for _, stroke := range centerlines.Strokes {
+		assert.Len(t, stroke.Widths, len(stroke.Points))
+		// Simplified down to nearly straight lines, three pixels wide
+		assert.LessOrEqual(t, len(stroke.Points), 4)
+		assert.InDelta(t, 3, stroke.Widths[len(stroke.Widths)/2], 0.5)
+	}
+
+	// A ring is a single closed stroke
+	ring := discBitmap(30, 12)
+	hole := discBitmap(30, 8)
+	for i := range ring.Pix {
+		ring.Pix[i] = ring.Pix[i] && !hole.Pix[i]
+	}
+	centerlines = TraceCenterlines(ring, OpacityColorFilledFunc, CenterlineOptions{MinBranchLength: 3})
+	assert.Empty(t, centerlines.Nodes)
+	if assert.Len(t, centerlines.Strokes, 1) {
+		stroke := centerlines.Strokes[0]
+		assert.Equal(t, -1, stroke.Start)
+		assert.Equal(t, stroke.Points[0], stroke.Points[len(stroke.Points)-1])
+		for _, p := range stroke.Points {
+			assert.InDelta(t, 10, math.Hypot(p.X-14.5, p.Y-14.5), 1.5)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package simpletrace
+
+import (
+	"image/color"
+	"math"
+)
+
+// A color in the CIELAB color space, under the D65 illuminant. Distances in
+// this space roughly match how different colors look.
+type Lab struct {
+	L float64
+	A float64
+	B float64
+}
+
+// A color in HSV space. H is in degrees from 0 to 360, and S and V are from 0
+// to 1.
+type HSV struct {
+	H float64
+	S float64
+	V float64
+}
+
+// Convert to bitmap by perceptual distance to a target color. Pixels within
+// maxDeltaE of the target, measured by the CIEDE2000 formula, are filled. A
+// ΔE of about 2 is barely noticeable, and 10 or so allows for moderate
+// variation in lighting or printing.
+//
+// As with DarkColorFilledFunc, pixels that fail OpacityColorFilledFunc are never
+// filled.
+func DeltaEColorFilledFunc(target color.Color, maxDeltaE float64) IsColorFilledFunc {
+	targetLab := LabFromColor(target)
+	return func(c color.Color) bool {
+		if !OpacityColorFilledFunc(c) {
+			return false
+		}
+		return LabFromColor(c).DeltaE2000(targetLab) <= maxDeltaE
+	}
+}
+
+// Convert to bitmap by an HSV range, like a chroma key. Pixels whose hue,
+// saturation and value all fall between min and max are filled. If min.H is
+// greater than max.H, the hue range wraps around through 0, so that reds can be
+// selected with a range like 340 to 20.
+//
+// As with DarkColorFilledFunc, pixels that fail OpacityColorFilledFunc are never
+// filled.
+func HSVRangeColorFilledFunc(min, max HSV) IsColorFilledFunc {
+	return func(c color.Color) bool {
+		if !OpacityColorFilledFunc(c) {
+			return false
+		}
+		hsv := HSVFromColor(c)
+		if hsv.S < min.S || hsv.S > max.S || hsv.V < min.V || hsv.V > max.V {
+			return false
+		}
+		if min.H <= max.H {
+			return hsv.H >= min.H && hsv.H <= max.H
+		}
+		return hsv.H >= min.H || hsv.H <= max.H
+	}
+}
+
+// Get the non-premultiplied sRGB channels of a color, from 0 to 1
+func unitRGBFromColor(c color.Color) (r, g, b float64) {
+	nrgba := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	return float64(nrgba.R) / 0xffff, float64(nrgba.G) / 0xffff, float64(nrgba.B) / 0xffff
+}
+
+func HSVFromColor(c color.Color) HSV {
+	r, g, b := unitRGBFromColor(c)
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	chroma := max - min
+
+	var hue float64
+	switch {
+	case chroma == 0:
+		hue = 0
+	case max == r:
+		hue = math.Mod((g-b)/chroma, 6)
+	case max == g:
+		hue = (b-r)/chroma + 2
+	default:
+		hue = (r-g)/chroma + 4
+	}
+	hue *= 60
+	if hue < 0 {
+		hue += 360
+	}
+
+	var saturation float64
+	if max > 0 {
+		saturation = chroma / max
+	}
+	return HSV{hue, saturation, max}
+}
+
+func LabFromColor(c color.Color) Lab {
+	r, g, b := unitRGBFromColor(c)
+	r, g, b = linearFromSRGB(r), linearFromSRGB(g), linearFromSRGB(b)
+
+	// Linear sRGB to XYZ, relative to the D65 white point
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+
+	fx, fy, fz := labCompand(x), labCompand(y), labCompand(z)
+	return Lab{
+		L: 116*fy - 16,
+		A: 500 * (fx - fy),
+		B: 200 * (fy - fz),
+	}
+}
+
+func linearFromSRGB(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func labCompand(t float64) float64 {
+	const epsilon = 216.0 / 24389
+	const kappa = 24389.0 / 27
+	if t > epsilon {
+		return math.Cbrt(t)
+	}
+	return (kappa*t + 16) / 116
+}
+
+// The CIE76 color difference, which is the straight line distance in Lab space
+func (lab Lab) DeltaE76(other Lab) float64 {
+	return math.Sqrt(squared(lab.L-other.L) + squared(lab.A-other.A) + squared(lab.B-other.B))
+}
+
+// The CIEDE2000 color difference, which corrects CIE76 for the eye's
+// sensitivity to differences in hue, chroma and lightness
+func (lab Lab) DeltaE2000(other Lab) float64 {
+	const degrees = math.Pi / 180
+
+	c1 := math.Hypot(lab.A, lab.B)
+	c2 := math.Hypot(other.A, other.B)
+	meanC := (c1 + c2) / 2
+	g := 0.5 * (1 - math.Sqrt(math.Pow(meanC, 7)/(math.Pow(meanC, 7)+math.Pow(25, 7))))
+
+	a1 := lab.A * (1 + g)
+	a2 := other.A * (1 + g)
+	c1 = math.Hypot(a1, lab.B)
+	c2 = math.Hypot(a2, other.B)
+	h1 := labHue(a1, lab.B)
+	h2 := labHue(a2, other.B)
+
+	deltaL := other.L - lab.L
+	deltaC := c2 - c1
+	var deltaH float64
+	if c1*c2 != 0 {
+		deltaH = h2 - h1
+		if deltaH > 180 {
+			deltaH -= 360
+		} else if deltaH < -180 {
+			deltaH += 360
+		}
+	}
+	deltaH = 2 * math.Sqrt(c1*c2) * math.Sin(deltaH/2*degrees)
+
+	meanL := (lab.L + other.L) / 2
+	meanC = (c1 + c2) / 2
+	meanH := h1 + h2
+	if c1*c2 != 0 {
+		if math.Abs(h1-h2) <= 180 {
+			meanH /= 2
+		} else if h1+h2 < 360 {
+			meanH = (meanH + 360) / 2
+		} else {
+			meanH = (meanH - 360) / 2
+		}
+	}
+
+	t := 1 -
+		0.17*math.Cos((meanH-30)*degrees) +
+		0.24*math.Cos(2*meanH*degrees) +
+		0.32*math.Cos((3*meanH+6)*degrees) -
+		0.20*math.Cos((4*meanH-63)*degrees)
+	deltaTheta := 30 * math.Exp(-squared((meanH-275)/25))
+	rc := 2 * math.Sqrt(math.Pow(meanC, 7)/(math.Pow(meanC, 7)+math.Pow(25, 7)))
+	sl := 1 + 0.015*squared(meanL-50)/math.Sqrt(20+squared(meanL-50))
+	sc := 1 + 0.045*meanC
+	sh := 1 + 0.015*meanC*t
+	rt := -math.Sin(2*deltaTheta*degrees) * rc
+
+	return math.Sqrt(
+		squared(deltaL/sl) +
+			squared(deltaC/sc) +
+			squared(deltaH/sh) +
+			rt*(deltaC/sc)*(deltaH/sh),
+	)
+}
+
+// Hue angle in degrees from 0 to 360
+func labHue(a, b float64) float64 {
+	if a == 0 && b == 0 {
+		return 0
+	}
+	hue := math.Atan2(b, a) * 180 / math.Pi
+	if hue < 0 {
+		hue += 360
+	}
+	return hue
+}
+
+func squared(x float64) float64 {
+	return x * x
+}
@@ -0,0 +1,42 @@
+package simpletrace
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeltaE2000(t *testing.T) {
+	// Reference pairs from Sharma, Wu and Dalal's CIEDE2000 test data
+	cases := []struct {
+		a, b     Lab
+		expected float64
+	}{
+		{Lab{50, 2.6772, -79.7751}, Lab{50, 0, -82.7485}, 2.0425},
+		{Lab{50, 2.5, 0}, Lab{73, 25, -18}, 27.1492},
+		{Lab{50, -1, 2}, Lab{50, 0, 0}, 2.3669},
+		{Lab{2.0776, 0.0795, -1.1350}, Lab{0.9033, -0.0636, -0.5514}, 0.9082},
+	}
+	for _, c := range cases {
+		assert.InDelta(t, c.expected, c.a.DeltaE2000(c.b), 1e-4)
+		assert.InDelta(t, c.expected, c.b.DeltaE2000(c.a), 1e-4)
+	}
+}
+
+func TestColorDistanceFilledFuncs(t *testing.T) {
+	brandRed := color.RGBA{0xd0, 0x20, 0x30, 0xff}
+	isRed := DeltaEColorFilledFunc(brandRed, 5)
+	assert.True(t, isRed(brandRed))
+	assert.True(t, isRed(color.RGBA{0xd2, 0x22, 0x30, 0xff}))
+	assert.False(t, isRed(color.RGBA{0x20, 0x20, 0xd0, 0xff}))
+	assert.False(t, isRed(color.RGBA{0, 0, 0, 0}))
+	// Premultiplied colors are compared by their real color
+	assert.True(t, isRed(color.RGBA{0xd0 * 3 / 4, 0x20 * 3 / 4, 0x30 * 3 / 4, 0xbf}))
+
+	isReddish := HSVRangeColorFilledFunc(HSV{340, 0.5, 0.3}, HSV{20, 1, 1})
+	assert.True(t, isReddish(brandRed))
+	assert.True(t, isReddish(color.RGBA{0xd0, 0x50, 0x20, 0xff}))
+	assert.False(t, isReddish(color.RGBA{0x20, 0xd0, 0x20, 0xff}))
+	assert.False(t, isReddish(color.RGBA{0xd0, 0xa0, 0xa0, 0xff}))
+}
@@ -0,0 +1,145 @@
+package simpletrace
+
+import "sort"
+
+// Split a trace result into convex polygons, for physics engines which only
+// handle convex shapes. Holes are respected, so the pieces cover exactly the
+// filled area.
+//
+// This uses the Hertel-Mehlhorn algorithm: the shapes are triangulated, and
+// then neighboring pieces are merged across their shared edges, longest edges
+// first, whenever the merged piece would still be convex. If maxVertices is at
+// least 3, pieces are never merged beyond that many vertices, which suits
+// engines like Box2D that have a fixed limit.
+func DecomposeConvex(polygons []Polygon, maxVertices int) []Polygon {
+	return FilledCounterclockwise.DecomposeConvex(polygons, maxVertices)
+}
+
+// DecomposeConvex for polygons in this winding convention. The pieces are wound
+// like filled polygons in this convention.
+func (c WindingConvention) DecomposeConvex(polygons []Polygon, maxVertices int) []Polygon {
+	polygons = c.Convert(polygons)
+	mesh := Triangulate(polygons, TriangulateDelaunay)
+
+	// Every triangle starts out as its own piece. Merged pieces are tracked with
+	// a union-find over the triangles.
+	pieces := make([][]int, len(mesh.Triangles))
+	parent := make([]int, len(mesh.Triangles))
+	edgeTriangles := make(map[[2]int][]int)
+	for t, triangle := range mesh.Triangles {
+		pieces[t] = []int{triangle[0], triangle[1], triangle[2]}
+		parent[t] = t
+		for i := 0; i < 3; i++ {
+			key := edgeKey(triangle[i], triangle[(i+1)%3])
+			edgeTriangles[key] = append(edgeTriangles[key], t)
+		}
+	}
+	var find func(t int) int
+	find = func(t int) int {
+		if parent[t] != t {
+			parent[t] = find(parent[t])
+		}
+		return parent[t]
+	}
+
+	// Edges between two triangles are the diagonals that can be removed
+	var diagonals [][2]int
+	for key, triangles := range edgeTriangles {
+		if len(triangles) == 2 {
+			diagonals = append(diagonals, key)
+		}
+	}
+	length := func(key [2]int) float64 {
+		return mesh.Vertices[key[0]].DistanceTo(mesh.Vertices[key[1]])
+	}
+	sort.Slice(diagonals, func(i, j int) bool {
+		li, lj := length(diagonals[i]), length(diagonals[j])
+		if li != lj {
+			return li > lj
+		}
+		return diagonals[i][0] < diagonals[j][0] || (diagonals[i][0] == diagonals[j][0] && diagonals[i][1] < diagonals[j][1])
+	})
+
+	for _, diagonal := range diagonals {
+		triangles := edgeTriangles[diagonal]
+		first, second := find(triangles[0]), find(triangles[1])
+		if first == second {
+			continue
+		}
+		merged := mergePieces(pieces[first], pieces[second], diagonal)
+		if merged == nil || (maxVertices >= 3 && len(merged) > maxVertices) || !mesh.isConvexPiece(merged) {
+			continue
+		}
+		parent[second] = first
+		pieces[first] = merged
+		pieces[second] = nil
+	}
+
+	var result []Polygon
+	for t := range pieces {
+		if find(t) != t {
+			continue
+		}
+		polygon := make(Polygon, len(pieces[t]))
+		for i, index := range pieces[t] {
+			polygon[i] = mesh.Vertices[index]
+		}
+		result = append(result, removeCollinearVertices(polygon))
+	}
+	return c.Convert(result)
+}
+
+// Join two pieces along an edge they share, returning nil if they can't be
+// joined into a single simple ring
+func mergePieces(p, q []int, edge [2]int) []int {
+	// Rotate a ring so that it starts at from and ends at to, which must be
+	// neighbors in the ring
+	rotate := func(ring []int, from, to int) []int {
+		n := len(ring)
+		for i := range ring {
+			if ring[i] == from && ring[(i+n-1)%n] == to {
+				rotated := make([]int, 0, n)
+				rotated = append(rotated, ring[i:]...)
+				return append(rotated, ring[:i]...)
+			}
+		}
+		return nil
+	}
+
+	// The edge runs one way around p and the other way around q
+	a, b := edge[0], edge[1]
+	pPath := rotate(p, b, a)
+	qPath := rotate(q, a, b)
+	if pPath == nil || qPath == nil {
+		a, b = b, a
+		pPath = rotate(p, b, a)
+		qPath = rotate(q, a, b)
+		if pPath == nil || qPath == nil {
+			return nil
+		}
+	}
+
+	merged := append(append([]int{}, pPath...), qPath[1:len(qPath)-1]...)
+	seen := make(map[int]bool)
+	for _, index := range merged {
+		if seen[index] {
+			return nil
+		}
+		seen[index] = true
+	}
+	return merged
+}
+
+// Whether every corner of the piece turns left. Straight corners aren't allowed,
+// since physics engines tend to reject them, and merging across them tends to
+// leave awkward wedges that block better merges later.
+func (mesh *Mesh) isConvexPiece(ring []int) bool {
+	n := len(ring)
+	for i := range ring {
+		a, b, c := mesh.Vertices[ring[(i+n-1)%n]], mesh.Vertices[ring[i]], mesh.Vertices[ring[(i+1)%n]]
+		if cross(a, b, c) <= 1e-12*a.DistanceTo(c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package simpletrace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecomposeConvex(t *testing.T) {
+	lShape := []Polygon{{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}}}
+	pieces := DecomposeConvex(lShape, 0)
+	assert.Len(t, pieces, 2)
+	assert.InDelta(t, 3, totalSignedArea(pieces), 1e-9)
+
+	polygons := TraceImage(imageFromRows(
+		"..............",
+		".XXXXXXXXXXXX.",
+		".XXXXXXXXXXXX.",
+		".XX...XXX..XX.",
+		".XX...XXX..XX.",
+		".XXXXXXXXXXXX.",
+		".XXXXX........",
+		".XXXXX........",
+		"..............",
+	), OpacityColorFilledFunc)
+
+	for _, maxVertices := range []int{0, 3, 8} {
+		pieces := DecomposeConvex(polygons, maxVertices)
+		assert.InDelta(t, totalSignedArea(polygons), totalSignedArea(pieces), 1e-9)
+		for _, piece := range pieces {
+			assert.True(t, piece.IsCounterClockwise())
+			if maxVertices > 0 {
+				assert.LessOrEqual(t, len(piece), maxVertices)
+			}
+			for i := range piece {
+				n := len(piece)
+				assert.GreaterOrEqual(t, cross(piece[(i+n-1)%n], piece[i], piece[(i+1)%n]), -1e-9)
+			}
+		}
+	}
+}
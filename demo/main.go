@@ -3,10 +3,12 @@ package main
 import (
 	"fmt"
 	"image"
-	_ "image/png"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
 	"os"
 
-	"github.com/fogleman/gg"
 	"github.com/osuushi/simpletrace"
 )
 
@@ -24,54 +26,76 @@ func main() {
 		fmt.Println(len(polygon), "points")
 	}
 
-	// Draw the polygons into an image
-	cx := gg.NewContext(image.Bounds().Dx()*4, image.Bounds().Dy()*4)
-	fmt.Println("Created context")
-	cx.SetRGB255(0, 0, 0)
-	cx.Clear()
+	// Draw the polygons into an image, scaled up by 4 to get a better view of the
+	// lines
+	const scale = 4
+	options := simpletrace.RasterizeOptions{Scale: scale, AntiAlias: true}
+	img := newFilledImage(image.Bounds().Dx()*scale, image.Bounds().Dy()*scale, color.Black)
 	fmt.Println("Drawing polygons")
 	for _, polygon := range polygons {
-		isHole := simpletrace.SignedAreaOfPolygon(polygon) < 0
-		if isHole {
-			cx.SetRGB255(255, 0, 0)
-		} else {
-			cx.SetRGB255(0, 0, 255)
+		lineColor := color.RGBA{0, 0, 255, 255}
+		if polygon.IsHole() {
+			lineColor = color.RGBA{255, 0, 0, 255}
 		}
+		var lines, points []simpletrace.Polygon
 		for i := 0; i < len(polygon); i++ {
 			nextI := (i + 1) % len(polygon)
-			// Draw scaled up by 4 to get a better view of the lines
-			cx.DrawLine(polygon[i].X*4, polygon[i].Y*4, polygon[nextI].X*4, polygon[nextI].Y*4)
+			lines = append(lines, lineQuad(polygon[i], polygon[nextI], 0.5/scale))
+			// Draw green points at the end points of the lines
+			points = append(points, lineQuad(polygon[i], polygon[i], 1.0/scale))
 		}
-		cx.Stroke()
-
-		// Draw green points at the end points of the lines
-		for _, p := range polygon {
-			cx.SetRGB255(0, 255, 0)
-			cx.DrawCircle(p.X*4, p.Y*4, 1)
-		}
-		cx.Fill()
+		simpletrace.DrawPolygons(img, lines, lineColor, options)
+		simpletrace.DrawPolygons(img, points, color.RGBA{0, 255, 0, 255}, options)
 	}
 	fmt.Println("saving image to demo.png")
-	cx.SavePNG("demo.png")
+	savePNG("demo.png", img)
 
 	// Reproduce the original image
-	cx = gg.NewContext(image.Bounds().Dx(), image.Bounds().Dy())
-	cx.SetRGB255(255, 255, 255)
-	cx.Clear()
-	cx.SetFillRuleWinding()
-	for _, polygon := range polygons {
-		cx.MoveTo(polygon[0].X, polygon[0].Y)
-		for i := 1; i < len(polygon); i++ {
-			cx.LineTo(polygon[i].X, polygon[i].Y)
-		}
-		cx.ClosePath()
-	}
-	cx.SetRGB255(0, 0, 0)
-	cx.Fill()
-	cx.SavePNG("demo-reproduced.png")
+	img = newFilledImage(image.Bounds().Dx(), image.Bounds().Dy(), color.White)
+	simpletrace.DrawPolygons(img, polygons, color.Black, simpletrace.RasterizeOptions{})
+	savePNG("demo-reproduced.png", img)
 }
 
 func LoadImageFromStdin() (image.Image, error) {
 	img, _, err := image.Decode(os.Stdin)
 	return img, err
 }
+
+func newFilledImage(width, height int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+// A filled rectangle around the line from a to b, extending halfWidth on every
+// side. If a and b are the same, this is a square around the point.
+func lineQuad(a, b simpletrace.Point, halfWidth float64) simpletrace.Polygon {
+	dx, dy := 1.0, 0.0
+	if length := math.Hypot(b.X-a.X, b.Y-a.Y); length > 0 {
+		dx, dy = (b.X-a.X)/length, (b.Y-a.Y)/length
+	}
+	// Along and across the line
+	ax, ay := dx*halfWidth, dy*halfWidth
+	cx, cy := -dy*halfWidth, dx*halfWidth
+	quad := simpletrace.Polygon{
+		{X: a.X - ax - cx, Y: a.Y - ay - cy},
+		{X: b.X + ax - cx, Y: b.Y + ay - cy},
+		{X: b.X + ax + cx, Y: b.Y + ay + cy},
+		{X: a.X - ax + cx, Y: a.Y - ay + cy},
+	}
+	if quad.IsHole() {
+		return quad.Reverse()
+	}
+	return quad
+}
+
+func savePNG(path string, img image.Image) {
+	file, err := os.Create(path)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		panic(err)
+	}
+}
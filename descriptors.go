@@ -0,0 +1,278 @@
+package simpletrace
+
+import "math"
+
+// The raw moments of an area up to third order. Mpq is the integral of x^p y^q
+// over the area, so M00 is the area, and M10/M00, M01/M00 is the centroid.
+//
+// Moments are additive, and the moments of a hole are negative because it is
+// wound clockwise, so the moments of a shape are the sums of the moments of its
+// polygons.
+type Moments struct {
+	M00, M10, M01, M20, M11, M02, M30, M21, M12, M03 float64
+}
+
+// A rectangle which may be rotated
+type OrientedRect struct {
+	Center Point
+	// The length of the sides along and across the angle. The width is the
+	// longer one.
+	Width, Height float64
+	// The angle of the width side from the x axis, in radians toward the y axis,
+	// between -π/2 and π/2
+	Angle float64
+}
+
+// Descriptors of a shape, for telling traced blobs apart. Angles are in
+// radians, measured from the x axis toward the y axis, which is clockwise on
+// screen since y points down.
+type ShapeDescriptors struct {
+	Area      float64
+	Perimeter float64
+	Centroid  Point
+	// Second moments about the centroid
+	Mu20, Mu11, Mu02 float64
+	// The polar moment of inertia of the area about its centroid, for a uniform
+	// density of 1
+	MomentOfInertia float64
+	// The angle of the major axis, between -π/2 and π/2
+	Orientation float64
+	// The eccentricity of the ellipse with the same second moments, which is 0
+	// for shapes as round as a circle or square, and approaches 1 for long thin
+	// shapes
+	Eccentricity float64
+	// 4π times the area over the square of the perimeter, which is 1 for a
+	// circle and smaller for everything else
+	Circularity float64
+	// Hu's seven moment invariants, which don't change when the shape is moved,
+	// scaled or rotated. The seventh changes sign when the shape is mirrored.
+	Hu [7]float64
+	// The number of islands minus the number of holes, which for a single shape
+	// is one minus its number of holes
+	EulerNumber int
+	// The smallest rectangle containing the shape
+	OrientedBounds OrientedRect
+}
+
+// The moments of the area enclosed by the polygon, which are negative if it is
+// wound clockwise, as holes are in the default convention
+func (p Polygon) Moments() Moments {
+	var m Moments
+	n := len(p)
+	for i := 0; i < n; i++ {
+		x0, y0 := p[i].X, p[i].Y
+		x1, y1 := p[(i+1)%n].X, p[(i+1)%n].Y
+		a := x0*y1 - x1*y0
+		m.M00 += a
+		m.M10 += a * (x0 + x1)
+		m.M01 += a * (y0 + y1)
+		m.M20 += a * (x0*x0 + x0*x1 + x1*x1)
+		m.M02 += a * (y0*y0 + y0*y1 + y1*y1)
+		m.M11 += a * (x0*y1 + 2*x0*y0 + 2*x1*y1 + x1*y0)
+		m.M30 += a * (x0*x0*x0 + x0*x0*x1 + x0*x1*x1 + x1*x1*x1)
+		m.M03 += a * (y0*y0*y0 + y0*y0*y1 + y0*y1*y1 + y1*y1*y1)
+		m.M21 += a * (x0*x0*(3*y0+y1) + 2*x0*x1*(y0+y1) + x1*x1*(y0+3*y1))
+		m.M12 += a * (y0*y0*(3*x0+x1) + 2*y0*y1*(x0+x1) + y1*y1*(x0+3*x1))
+	}
+	m.M00 /= 2
+	m.M10 /= 6
+	m.M01 /= 6
+	m.M20 /= 12
+	m.M02 /= 12
+	m.M11 /= 24
+	m.M30 /= 20
+	m.M03 /= 20
+	m.M21 /= 60
+	m.M12 /= 60
+	return m
+}
+
+func (m Moments) negate() Moments {
+	return Moments{
+		-m.M00, -m.M10, -m.M01,
+		-m.M20, -m.M11, -m.M02,
+		-m.M30, -m.M21, -m.M12, -m.M03,
+	}
+}
+
+func (m Moments) add(other Moments) Moments {
+	return Moments{
+		m.M00 + other.M00, m.M10 + other.M10, m.M01 + other.M01,
+		m.M20 + other.M20, m.M11 + other.M11, m.M02 + other.M02,
+		m.M30 + other.M30, m.M21 + other.M21, m.M12 + other.M12, m.M03 + other.M03,
+	}
+}
+
+// The moments of the filled area of the shape, in either winding convention
+func (s Shape) Moments() Moments {
+	m := s.Outer.Moments()
+	for _, hole := range s.Holes {
+		m = m.add(hole.Moments())
+	}
+	if s.Outer.SignedArea() < 0 {
+		m = m.negate()
+	}
+	return m
+}
+
+func (s Shape) Descriptors() ShapeDescriptors {
+	m := s.Moments()
+	d := ShapeDescriptors{
+		Area:        m.M00,
+		Perimeter:   s.Perimeter(),
+		EulerNumber: 1 - len(s.Holes),
+	}
+	if m.M00 == 0 {
+		d.Centroid = s.Centroid()
+		return d
+	}
+
+	cx, cy := m.M10/m.M00, m.M01/m.M00
+	d.Centroid = Point{cx, cy}
+	d.Mu20 = m.M20 - cx*m.M10
+	d.Mu02 = m.M02 - cy*m.M01
+	d.Mu11 = m.M11 - cx*m.M01
+	mu30 := m.M30 - 3*cx*m.M20 + 2*cx*cx*m.M10
+	mu03 := m.M03 - 3*cy*m.M02 + 2*cy*cy*m.M01
+	mu21 := m.M21 - 2*cx*m.M11 - cy*m.M20 + 2*cx*cx*m.M01
+	mu12 := m.M12 - 2*cy*m.M11 - cx*m.M02 + 2*cy*cy*m.M10
+
+	d.MomentOfInertia = d.Mu20 + d.Mu02
+	d.Orientation = math.Atan2(2*d.Mu11, d.Mu20-d.Mu02) / 2
+	// Eigenvalues of the covariance matrix, which are the second moments along
+	// the major and minor axes
+	spread := math.Hypot((d.Mu20-d.Mu02)/2, d.Mu11)
+	major := (d.Mu20+d.Mu02)/2 + spread
+	minor := (d.Mu20+d.Mu02)/2 - spread
+	if major > 0 {
+		d.Eccentricity = math.Sqrt(math.Max(0, 1-minor/major))
+	}
+	if d.Perimeter > 0 {
+		d.Circularity = 4 * math.Pi * d.Area / (d.Perimeter * d.Perimeter)
+	}
+
+	// Scale invariant moments, and from those, Hu's rotation invariants
+	normalize := func(mu float64, order int) float64 {
+		return mu / math.Pow(m.M00, 1+float64(order)/2)
+	}
+	n20, n11, n02 := normalize(d.Mu20, 2), normalize(d.Mu11, 2), normalize(d.Mu02, 2)
+	n30, n21, n12, n03 := normalize(mu30, 3), normalize(mu21, 3), normalize(mu12, 3), normalize(mu03, 3)
+	d.Hu[0] = n20 + n02
+	d.Hu[1] = (n20-n02)*(n20-n02) + 4*n11*n11
+	d.Hu[2] = (n30-3*n12)*(n30-3*n12) + (3*n21-n03)*(3*n21-n03)
+	d.Hu[3] = (n30+n12)*(n30+n12) + (n21+n03)*(n21+n03)
+	d.Hu[4] = (n30-3*n12)*(n30+n12)*((n30+n12)*(n30+n12)-3*(n21+n03)*(n21+n03)) +
+		(3*n21-n03)*(n21+n03)*(3*(n30+n12)*(n30+n12)-(n21+n03)*(n21+n03))
+	d.Hu[5] = (n20-n02)*((n30+n12)*(n30+n12)-(n21+n03)*(n21+n03)) +
+		4*n11*(n30+n12)*(n21+n03)
+	d.Hu[6] = (3*n21-n03)*(n30+n12)*((n30+n12)*(n30+n12)-3*(n21+n03)*(n21+n03)) -
+		(n30-3*n12)*(n21+n03)*(3*(n30+n12)*(n30+n12)-(n21+n03)*(n21+n03))
+
+	d.OrientedBounds = MinimumBoundingRect(s.Outer)
+	return d
+}
+
+// Describe every shape in a trace result, in the order of ShapesFromPolygons
+func DescribeShapes(polygons []Polygon) []ShapeDescriptors {
+	return FilledCounterclockwise.DescribeShapes(polygons)
+}
+
+// DescribeShapes for polygons in this winding convention
+func (c WindingConvention) DescribeShapes(polygons []Polygon) []ShapeDescriptors {
+	shapes := c.ShapesFromPolygons(polygons)
+	descriptors := make([]ShapeDescriptors, len(shapes))
+	for i, shape := range shapes {
+		descriptors[i] = shape.Descriptors()
+	}
+	return descriptors
+}
+
+// The number of filled polygons minus the number of holes in a trace result
+func EulerNumber(polygons []Polygon) int {
+	return FilledCounterclockwise.EulerNumber(polygons)
+}
+
+// EulerNumber for polygons in this winding convention
+func (c WindingConvention) EulerNumber(polygons []Polygon) int {
+	euler := 0
+	for _, polygon := range polygons {
+		if c.IsHole(polygon) {
+			euler--
+		} else {
+			euler++
+		}
+	}
+	return euler
+}
+
+// The smallest area rectangle containing all of the vertices of the polygons.
+// One side of the smallest rectangle always lies along an edge of the convex
+// hull, so each hull edge is tried in turn, with calipers along and across it.
+func MinimumBoundingRect(polygons ...Polygon) OrientedRect {
+	hull := ConvexHull(polygons...)
+	if len(hull) == 0 {
+		return OrientedRect{}
+	}
+	if len(hull) < 3 {
+		// A point or a line, which has no area
+		a, b := hull[0], hull[len(hull)-1]
+		return OrientedRect{
+			Center: Point{(a.X + b.X) / 2, (a.Y + b.Y) / 2},
+			Width:  a.DistanceTo(b),
+			Angle:  math.Atan2(b.Y-a.Y, b.X-a.X),
+		}.normalized()
+	}
+
+	best := OrientedRect{}
+	bestArea := math.Inf(1)
+	for i := range hull {
+		a, b := hull[i], hull[(i+1)%len(hull)]
+		length := a.DistanceTo(b)
+		ux, uy := (b.X-a.X)/length, (b.Y-a.Y)/length
+		minU, maxU := math.Inf(1), math.Inf(-1)
+		minV, maxV := math.Inf(1), math.Inf(-1)
+		for _, p := range hull {
+			u := (p.X-a.X)*ux + (p.Y-a.Y)*uy
+			v := -(p.X-a.X)*uy + (p.Y-a.Y)*ux
+			minU, maxU = math.Min(minU, u), math.Max(maxU, u)
+			minV, maxV = math.Min(minV, v), math.Max(maxV, v)
+		}
+		if area := (maxU - minU) * (maxV - minV); area < bestArea {
+			bestArea = area
+			centerU, centerV := (minU+maxU)/2, (minV+maxV)/2
+			best = OrientedRect{
+				Center: Point{a.X + centerU*ux - centerV*uy, a.Y + centerU*uy + centerV*ux},
+				Width:  maxU - minU,
+				Height: maxV - minV,
+				Angle:  math.Atan2(uy, ux),
+			}
+		}
+	}
+	return best.normalized()
+}
+
+// The same rectangle with the width as the longer side, and the angle in range
+func (r OrientedRect) normalized() OrientedRect {
+	if r.Height > r.Width {
+		r.Width, r.Height = r.Height, r.Width
+		r.Angle += math.Pi / 2
+	}
+	// A rectangle turned half way around is the same rectangle
+	for r.Angle > math.Pi/2 {
+		r.Angle -= math.Pi
+	}
+	for r.Angle <= -math.Pi/2 {
+		r.Angle += math.Pi
+	}
+	return r
+}
+
+// The corners of the rectangle, as a filled polygon
+func (r OrientedRect) Polygon() Polygon {
+	sin, cos := math.Sincos(r.Angle)
+	corner := func(u, v float64) Point {
+		return Point{r.Center.X + u*cos - v*sin, r.Center.Y + u*sin + v*cos}
+	}
+	w, h := r.Width/2, r.Height/2
+	return Polygon{corner(-w, -h), corner(w, -h), corner(w, h), corner(-w, h)}
+}
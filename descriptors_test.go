@@ -0,0 +1,88 @@
+package simpletrace
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoments(t *testing.T) {
+	rect := Polygon{{0, 0}, {4, 0}, {4, 2}, {0, 2}}
+	m := rect.Moments()
+	assert.InDelta(t, 8, m.M00, 1e-9)
+	assert.InDelta(t, 16, m.M10, 1e-9)
+	assert.InDelta(t, 8, m.M01, 1e-9)
+	// Integral of x^2 over the rectangle is 4^3/3 * 2
+	assert.InDelta(t, 128.0/3, m.M20, 1e-9)
+	assert.InDelta(t, 16, m.M11, 1e-9)
+	// Integral of x^3 is 4^4/4 * 2
+	assert.InDelta(t, 128, m.M30, 1e-9)
+	// Integral of x^2 y is 4^3/3 * 2^2/2
+	assert.InDelta(t, 128.0/3, m.M21, 1e-9)
+
+	// Holes subtract
+	hole := Polygon{{1, 0.5}, {2, 0.5}, {2, 1.5}, {1, 1.5}}.Reverse()
+	shape := Shape{Outer: rect, Holes: []Polygon{hole}}
+	assert.InDelta(t, 7, shape.Moments().M00, 1e-9)
+}
+
+func TestShapeDescriptors(t *testing.T) {
+	rect := Shape{Outer: Polygon{{0, 0}, {4, 0}, {4, 2}, {0, 2}}}
+	d := rect.Descriptors()
+	assert.InDelta(t, 8, d.Area, 1e-9)
+	assert.InDelta(t, 12, d.Perimeter, 1e-9)
+	assert.InDelta(t, 2, d.Centroid.X, 1e-9)
+	assert.InDelta(t, 1, d.Centroid.Y, 1e-9)
+	assert.InDelta(t, 64.0/6, d.Mu20, 1e-9)
+	assert.InDelta(t, 16.0/6, d.Mu02, 1e-9)
+	assert.InDelta(t, 0, d.Mu11, 1e-9)
+	assert.InDelta(t, 80.0/6, d.MomentOfInertia, 1e-9)
+	assert.InDelta(t, 0, d.Orientation, 1e-9)
+	assert.InDelta(t, math.Sqrt(0.75), d.Eccentricity, 1e-9)
+	assert.InDelta(t, 4*math.Pi*8/144, d.Circularity, 1e-9)
+	assert.Equal(t, 1, d.EulerNumber)
+	assert.InDelta(t, 4, d.OrientedBounds.Width*d.OrientedBounds.Height/2, 1e-9)
+
+	// Rotating, scaling and moving doesn't change the Hu moments, but does change
+	// the orientation
+	transform := RotationAffine(0.5).Then(ScaleAffine(3, 3)).Then(TranslationAffine(10, -4))
+	moved := rect.Transform(transform).Descriptors()
+	for i := range d.Hu {
+		assert.InDelta(t, d.Hu[i], moved.Hu[i], 1e-9)
+	}
+	assert.InDelta(t, 0.5, moved.Orientation, 1e-9)
+	assert.InDelta(t, 12, moved.OrientedBounds.Width, 1e-9)
+	assert.InDelta(t, 0.5, moved.OrientedBounds.Angle, 1e-9)
+	assert.InDelta(t, 6, moved.OrientedBounds.Height, 1e-9)
+	assert.InDelta(t, 72, moved.OrientedBounds.Polygon().Area(), 1e-9)
+	for _, p := range moved.OrientedBounds.Polygon() {
+		assert.True(t, ConvexHull(rect.Transform(transform).Outer).DistanceTo(p) < 1e-9)
+	}
+
+	// A traced disc is nearly circular
+	polygons := TraceImage(discBitmap(60, 25), OpacityColorFilledFunc)
+	disc := DescribeShapes(polygons)[0]
+	assert.Greater(t, disc.Circularity, 0.9)
+	assert.Less(t, disc.Eccentricity, 0.2)
+}
+
+func TestEulerNumber(t *testing.T) {
+	img := imageFromRows(
+		"...........",
+		".XXXXXXXXX.",
+		".X.X.XXXXX.",
+		".XXXXXXXXX.",
+		"...........",
+		".XXX.......",
+		"...........",
+	)
+	polygons := TraceImage(img, OpacityColorFilledFunc)
+	assert.Equal(t, 0, EulerNumber(polygons))
+	descriptors := DescribeShapes(polygons)
+	total := 0
+	for _, d := range descriptors {
+		total += d.EulerNumber
+	}
+	assert.Equal(t, 0, total)
+}
@@ -0,0 +1,191 @@
+package simpletrace
+
+import (
+	"math"
+)
+
+// How closely a trace result matches the bitmap it was traced from, as
+// reported by MeasureFidelity
+type FidelityReport struct {
+	// Intersection over union of the filled pixels of the bitmap and the
+	// rasterized polygons. This is 1 for a perfect match, and also when both are
+	// empty.
+	IoU float64
+	// Pixels that the polygons fill but the bitmap doesn't
+	FalsePositives int
+	// Pixels that the bitmap fills but the polygons don't
+	FalseNegatives int
+	// Pixels that are classified differently by the bitmap and the polygons
+	Difference *Bitmap
+	// The Hausdorff distance in pixels between the raw marching squares contour
+	// of the bitmap and the polygons' outlines. This is the farthest either one
+	// strays from the other, and measures how much simplification moved the
+	// outline. It is infinite if one is empty and the other isn't.
+	Hausdorff float64
+}
+
+// How far apart points are placed along outlines when measuring Hausdorff
+// distance, in pixels
+const hausdorffSampleSpacing = 0.1
+
+// Measure how faithfully polygons traced from a bitmap reproduce it. The
+// polygons are rasterized back onto the bitmap's grid with RasterizeBitmap and
+// compared pixel by pixel, and their outlines are compared against the
+// unsimplified contour that the tracer started from.
+//
+// Any preprocessing filters should already have been applied to the bitmap, so
+// that it is the bitmap that was actually traced.
+func MeasureFidelity(bitmap *Bitmap, polygons []Polygon) FidelityReport {
+	return FilledCounterclockwise.MeasureFidelity(bitmap, polygons)
+}
+
+// MeasureFidelity for polygons in this winding convention
+func (c WindingConvention) MeasureFidelity(bitmap *Bitmap, polygons []Polygon) FidelityReport {
+	rasterized := c.RasterizeBitmap(polygons, bitmap.Rect)
+	report := FidelityReport{Difference: NewBitmap(bitmap.Rect)}
+	intersection, union := 0, 0
+	for i, filled := range bitmap.Pix {
+		traced := rasterized.Pix[i]
+		if filled && traced {
+			intersection++
+		}
+		if filled || traced {
+			union++
+		}
+		if filled != traced {
+			report.Difference.Pix[i] = true
+			if traced {
+				report.FalsePositives++
+			} else {
+				report.FalseNegatives++
+			}
+		}
+	}
+	report.IoU = 1
+	if union > 0 {
+		report.IoU = float64(intersection) / float64(union)
+	}
+
+	var traced [][2]Point
+	for _, polygon := range polygons {
+		for i := range polygon {
+			traced = append(traced, [2]Point{polygon[i], polygon[(i+1)%len(polygon)]})
+		}
+	}
+	raw := rawContourSegments(bitmap)
+	report.Hausdorff = math.Max(directedHausdorff(raw, traced), directedHausdorff(traced, raw))
+	return report
+}
+
+// The segments of the unsimplified marching squares contour of a bitmap. Each
+// one joins the midpoints of two sides of a square.
+func rawContourSegments(bitmap *Bitmap) [][2]Point {
+	var segments [][2]Point
+	for _, square := range getSquaresForBitmap(bitmap) {
+		for from := Direction(0); from < DirectionInvalid; from++ {
+			to := square.DirectionFor(from)
+			// Every path through a square is listed in both directions, so only
+			// take one of them
+			if to == DirectionInvalid || from.Reverse() > to {
+				continue
+			}
+			a, b := square.CornerPointsInDirection(from.Reverse())
+			c, d := square.CornerPointsInDirection(to)
+			segments = append(segments, [2]Point{
+				{(a.X + b.X) / 2, (a.Y + b.Y) / 2},
+				{(c.X + d.X) / 2, (c.Y + d.Y) / 2},
+			})
+		}
+	}
+	return segments
+}
+
+// The farthest any point on the segments in from lies from the segments in to
+func directedHausdorff(from, to [][2]Point) float64 {
+	if len(from) == 0 {
+		return 0
+	}
+	if len(to) == 0 {
+		return math.Inf(1)
+	}
+	grid := newSegmentGrid(to)
+	farthest := 0.0
+	for _, segment := range from {
+		steps := int(math.Ceil(segment[0].DistanceTo(segment[1]) / hausdorffSampleSpacing))
+		for i := 0; i <= steps; i++ {
+			t := 0.0
+			if steps > 0 {
+				t = float64(i) / float64(steps)
+			}
+			p := Point{
+				segment[0].X + (segment[1].X-segment[0].X)*t,
+				segment[0].Y + (segment[1].Y-segment[0].Y)*t,
+			}
+			farthest = math.Max(farthest, grid.distanceTo(p, math.Inf(1)))
+		}
+	}
+	return farthest
+}
+
+// A uniform grid of one pixel cells, each listing the segments whose bounds
+// overlap it, for finding the nearest segment to a point
+type segmentGrid struct {
+	segments [][2]Point
+	cells    map[IPoint][]int
+	bounds   Rect
+}
+
+func newSegmentGrid(segments [][2]Point) *segmentGrid {
+	grid := &segmentGrid{segments: segments, cells: make(map[IPoint][]int)}
+	for i, segment := range segments {
+		if i == 0 {
+			grid.bounds = Rect{segment[0], segment[0]}
+		}
+		grid.bounds = grid.bounds.extend(segment[0]).extend(segment[1])
+		minX := int(math.Floor(math.Min(segment[0].X, segment[1].X)))
+		maxX := int(math.Floor(math.Max(segment[0].X, segment[1].X)))
+		minY := int(math.Floor(math.Min(segment[0].Y, segment[1].Y)))
+		maxY := int(math.Floor(math.Max(segment[0].Y, segment[1].Y)))
+		for y := minY; y <= maxY; y++ {
+			for x := minX; x <= maxX; x++ {
+				cell := IPoint{x, y}
+				grid.cells[cell] = append(grid.cells[cell], i)
+			}
+		}
+	}
+	return grid
+}
+
+// The distance from a point to the nearest segment in the grid, searching rings
+// of cells outward from the point's cell until no closer segment can be found.
+// The search gives up past limit, returning something no less than limit.
+func (grid *segmentGrid) distanceTo(p Point, limit float64) float64 {
+	center := IPoint{int(math.Floor(p.X)), int(math.Floor(p.Y))}
+	// No segment is closer than the grid's bounds, so there's no use searching
+	// more rings than it takes to cover them from here
+	reach := grid.bounds.DistanceTo(p) + math.Max(grid.bounds.Width(), grid.bounds.Height())
+	best := math.Inf(1)
+	for ring := 0; ; ring++ {
+		visit := func(x, y int) {
+			for _, i := range grid.cells[IPoint{x, y}] {
+				best = math.Min(best, distanceToSegment(p, grid.segments[i][0], grid.segments[i][1]))
+			}
+		}
+		if ring == 0 {
+			visit(center.X, center.Y)
+		} else {
+			for d := -ring; d <= ring; d++ {
+				visit(center.X+d, center.Y-ring)
+				visit(center.X+d, center.Y+ring)
+			}
+			for d := -ring + 1; d < ring; d++ {
+				visit(center.X-ring, center.Y+d)
+				visit(center.X+ring, center.Y+d)
+			}
+		}
+		// Anything in a farther ring is at least this far away
+		if best <= float64(ring) || float64(ring) > math.Min(reach, limit)+1 {
+			return best
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package simpletrace
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func discBitmap(size int, radius float64) *Bitmap {
+	bitmap := NewBitmap(image.Rect(0, 0, size, size))
+	center := float64(size-1) / 2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			bitmap.SetFilled(x, y, math.Hypot(float64(x)-center, float64(y)-center) < radius)
+		}
+	}
+	return bitmap
+}
+
+func TestMeasureFidelity(t *testing.T) {
+	bitmap := discBitmap(40, 15)
+	polygons := TraceImage(bitmap, OpacityColorFilledFunc)
+	report := MeasureFidelity(bitmap, polygons)
+	assert.Greater(t, report.IoU, 0.95)
+	assert.Less(t, report.Hausdorff, 1.0)
+	assert.Greater(t, report.Hausdorff, 0.0)
+
+	differences := 0
+	for _, different := range report.Difference.Pix {
+		if different {
+			differences++
+		}
+	}
+	assert.Equal(t, report.FalsePositives+report.FalseNegatives, differences)
+
+	// Moving the trace makes it much worse
+	shifted := make([]Polygon, len(polygons))
+	for i, polygon := range polygons {
+		shifted[i] = polygon.Translate(3, 0)
+	}
+	report = MeasureFidelity(bitmap, shifted)
+	assert.Less(t, report.IoU, 0.9)
+	assert.Greater(t, report.FalsePositives, 0)
+	assert.Greater(t, report.FalseNegatives, 0)
+	assert.InDelta(t, 3, report.Hausdorff, 0.5)
+
+	report = MeasureFidelity(bitmap, nil)
+	assert.Equal(t, 0.0, report.IoU)
+	assert.True(t, math.IsInf(report.Hausdorff, 1))
+
+	report = MeasureFidelity(NewBitmap(image.Rect(0, 0, 4, 4)), nil)
+	assert.Equal(t, 1.0, report.IoU)
+	assert.Equal(t, 0.0, report.Hausdorff)
+}
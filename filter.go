@@ -0,0 +1,76 @@
+package simpletrace
+
+import "math"
+
+// Drop small filled polygons and holes from a trace result, by the area and
+// pixel count thresholds in the options.
+//
+// Dropping a filled polygon also drops everything inside it. Dropping a hole
+// merges it into the filled polygon around it, so any islands inside the hole
+// are dropped as well, since they are now part of the filled region.
+func filterSpeckles(polygons []Polygon, options TraceOptions) []Polygon {
+	if options.MinFilledArea <= 0 && options.MinHoleArea <= 0 && options.MinFilledPixels <= 0 && options.MinHolePixels <= 0 {
+		return polygons
+	}
+
+	keep := make([]bool, len(polygons))
+	var visit func(nodes []*polygonNode)
+	visit = func(nodes []*polygonNode) {
+		for _, node := range nodes {
+			minArea, minPixels := options.MinFilledArea, options.MinFilledPixels
+			if SignedAreaOfPolygon(polygons[node.index]) < 0 {
+				minArea, minPixels = options.MinHoleArea, options.MinHolePixels
+			}
+			if node.area < minArea {
+				continue
+			}
+			if minPixels > 0 && regionPixelCount(polygons, node) < minPixels {
+				continue
+			}
+			keep[node.index] = true
+			visit(node.children)
+		}
+	}
+	visit(buildPolygonTree(polygons))
+
+	// Preserve the original order of the polygons
+	var result []Polygon
+	for i, polygon := range polygons {
+		if keep[i] {
+			result = append(result, polygon)
+		}
+	}
+	return result
+}
+
+// The number of pixels in the region a traced polygon encloses, not counting
+// the regions of the polygons directly inside it. For a filled polygon these
+// are its filled pixels, and for a hole its empty ones. Traced outlines never
+// cross pixel centers, so a pixel is inside a polygon exactly when its center
+// is.
+func regionPixelCount(polygons []Polygon, node *polygonNode) int {
+	count := pixelCentersInside(polygons[node.index])
+	for _, child := range node.children {
+		count -= pixelCentersInside(polygons[child.index])
+	}
+	return count
+}
+
+// Count the pixel centers inside a polygon, whichever way it is wound
+func pixelCentersInside(polygon Polygon) int {
+	if SignedAreaOfPolygon(polygon) < 0 {
+		polygon = polygon.Reverse()
+	}
+	bounds := polygon.Bounds()
+	count := 0
+	for y := math.Ceil(bounds.Min.Y); y <= bounds.Max.Y; y++ {
+		fillSpans([]Polygon{polygon}, y, func(start, end float64) {
+			first := int(math.Floor(start)) + 1
+			last := int(math.Ceil(end)) - 1
+			if last >= first {
+				count += last - first + 1
+			}
+		})
+	}
+	return count
+}
@@ -0,0 +1,107 @@
+package simpletrace
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Build an image from rows of text, where 'X' is an opaque pixel and anything
+// else is transparent.
+func imageFromRows(rows ...string) image.Image {
+	img := image.NewAlpha(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			if c == 'X' {
+				img.SetAlpha(x, y, color.Alpha{0xff})
+			}
+		}
+	}
+	return img
+}
+
+func countWindings(polygons []Polygon) (filled int, holes int) {
+	for _, polygon := range polygons {
+		if SignedAreaOfPolygon(polygon) > 0 {
+			filled++
+		} else {
+			holes++
+		}
+	}
+	return
+}
+
+func TestFilterSpeckles(t *testing.T) {
+	img := imageFromRows(
+		"..............",
+		".XXXXXXXXXXXX.",
+		".XXXXXXXXXXXX.",
+		".XX.XXX.....X.",
+		".XXXXXX..X..X.",
+		".XXXXXX.....X.",
+		".XXXXXXXXXXXX.",
+		"..............",
+		"...........X..",
+		"..............",
+	)
+
+	filled, holes := countWindings(TraceImage(img, OpacityColorFilledFunc))
+	assert.Equal(t, 3, filled)
+	assert.Equal(t, 2, holes)
+
+	// Dropping specks removes the lone pixels, including the one inside the hole
+	filled, holes = countWindings(TraceImageWithOptions(img, OpacityColorFilledFunc, TraceOptions{MinFilledArea: 2}))
+	assert.Equal(t, 1, filled)
+	assert.Equal(t, 2, holes)
+
+	// Filling the small hole leaves the large one alone
+	filled, holes = countWindings(TraceImageWithOptions(img, OpacityColorFilledFunc, TraceOptions{MinHoleArea: 2}))
+	assert.Equal(t, 3, filled)
+	assert.Equal(t, 1, holes)
+
+	// Filling the large hole swallows the island inside it
+	filled, holes = countWindings(TraceImageWithOptions(img, OpacityColorFilledFunc, TraceOptions{MinHoleArea: 100}))
+	assert.Equal(t, 2, filled)
+	assert.Equal(t, 0, holes)
+
+	// Pixel counts work the same way
+	filled, holes = countWindings(TraceImageWithOptions(img, OpacityColorFilledFunc, TraceOptions{MinFilledPixels: 2}))
+	assert.Equal(t, 1, filled)
+	assert.Equal(t, 2, holes)
+	filled, holes = countWindings(TraceImageWithOptions(img, OpacityColorFilledFunc, TraceOptions{MinHolePixels: 2}))
+	assert.Equal(t, 3, filled)
+	assert.Equal(t, 1, holes)
+
+	// The large hole has 15 pixels, but one of them belongs to the island
+	filled, holes = countWindings(TraceImageWithOptions(img, OpacityColorFilledFunc, TraceOptions{MinHolePixels: 14}))
+	assert.Equal(t, 3, filled)
+	assert.Equal(t, 1, holes)
+	filled, holes = countWindings(TraceImageWithOptions(img, OpacityColorFilledFunc, TraceOptions{MinHolePixels: 15}))
+	assert.Equal(t, 2, filled)
+	assert.Equal(t, 0, holes)
+}
+
+func TestRegionPixelCount(t *testing.T) {
+	img := imageFromRows(
+		"........",
+		".XXXXXX.",
+		".X....X.",
+		".X.XX.X.",
+		".X....X.",
+		".XXXXXX.",
+		"........",
+	)
+	polygons := TraceImage(img, OpacityColorFilledFunc)
+	var counts []int
+	var visit func(nodes []*polygonNode)
+	visit = func(nodes []*polygonNode) {
+		for _, node := range nodes {
+			counts = append(counts, regionPixelCount(polygons, node))
+			visit(node.children)
+		}
+	}
+	visit(buildPolygonTree(polygons))
+	assert.Equal(t, []int{18, 10, 2}, counts)
+}
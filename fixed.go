@@ -0,0 +1,116 @@
+package simpletrace
+
+import (
+	"fmt"
+	"math"
+)
+
+// A point on a fixed-point grid, with a whole number of grid steps per pixel.
+// This is the form that integer geometry libraries like Clipper expect.
+type FixedPoint struct {
+	X, Y int64
+}
+
+type FixedPolygon []FixedPoint
+
+// The point in pixel coordinates, for a grid with subdivisions steps per pixel
+func (p FixedPoint) Point(subdivisions int) Point {
+	return Point{float64(p.X) / float64(subdivisions), float64(p.Y) / float64(subdivisions)}
+}
+
+// The polygon in pixel coordinates, for a grid with subdivisions steps per pixel
+func (p FixedPolygon) Polygon(subdivisions int) Polygon {
+	polygon := make(Polygon, len(p))
+	for i, point := range p {
+		polygon[i] = point.Point(subdivisions)
+	}
+	return polygon
+}
+
+// Twice the signed area of the polygon, in square grid steps. Doubling the area
+// keeps it a whole number.
+func (p FixedPolygon) DoubleSignedArea() int64 {
+	var area int64
+	for i, a := range p {
+		b := p[(i+1)%len(p)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return area
+}
+
+// The error returned by ToFixed when snapping to the grid breaks the guarantees
+// checked by Validate. Violations refer to the snapped polygons, in grid
+// coordinates.
+type SnapError struct {
+	Violations []Violation
+}
+
+func (e *SnapError) Error() string {
+	message := fmt.Sprintf("snapping to the fixed-point grid made the polygons invalid: %v", e.Violations[0].Error())
+	if len(e.Violations) > 1 {
+		message += fmt.Sprintf(" (and %d more)", len(e.Violations)-1)
+	}
+	return message
+}
+
+// Scale polygons by subdivisions and round each vertex to the nearest integer.
+// Vertices which snap onto the same point as their neighbor, or onto the line
+// between their neighbors, are removed. Subdivisions less than 1 are treated
+// as 1.
+//
+// Snapping can make polygons cross or touch, or collapse small ones, so the
+// result is checked with Validate. If it is no longer valid, the snapped
+// polygons are returned along with a *SnapError describing the problems. Traced
+// vertices lie on the half-pixel grid, so traces snap exactly whenever
+// subdivisions is even, and can only break after they have been transformed,
+// resampled or offset.
+func ToFixed(polygons []Polygon, subdivisions int) ([]FixedPolygon, error) {
+	return FilledCounterclockwise.ToFixed(polygons, subdivisions)
+}
+
+// ToFixed for polygons in this winding convention, which the snapped polygons
+// are validated against
+func (c WindingConvention) ToFixed(polygons []Polygon, subdivisions int) ([]FixedPolygon, error) {
+	if subdivisions < 1 {
+		subdivisions = 1
+	}
+	scale := float64(subdivisions)
+
+	result := make([]FixedPolygon, len(polygons))
+	for i, polygon := range polygons {
+		fixed := make(FixedPolygon, 0, len(polygon))
+		for _, p := range polygon {
+			fixed = append(fixed, FixedPoint{int64(math.Round(p.X * scale)), int64(math.Round(p.Y * scale))})
+		}
+		result[i] = removeFixedCollinearVertices(fixed)
+	}
+
+	// Integer coordinates are exact as floats, so validating in grid coordinates
+	// checks exactly what the caller gets
+	unscaled := make([]Polygon, len(result))
+	for i, polygon := range result {
+		unscaled[i] = polygon.Polygon(1)
+	}
+	if violations := c.Validate(unscaled); len(violations) > 0 {
+		return result, &SnapError{violations}
+	}
+	return result, nil
+}
+
+// Remove repeated vertices and vertices on the line through their neighbors,
+// until there are none left
+func removeFixedCollinearVertices(polygon FixedPolygon) FixedPolygon {
+	for changed := true; changed && len(polygon) >= 3; {
+		changed = false
+		for i := 0; i < len(polygon) && len(polygon) >= 3; i++ {
+			n := len(polygon)
+			a, b, c := polygon[(i+n-1)%n], polygon[i], polygon[(i+1)%n]
+			if (b.X-a.X)*(c.Y-a.Y)-(b.Y-a.Y)*(c.X-a.X) == 0 {
+				polygon = append(polygon[:i:i], polygon[i+1:]...)
+				changed = true
+				i--
+			}
+		}
+	}
+	return polygon
+}
@@ -0,0 +1,36 @@
+package simpletrace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToFixed(t *testing.T) {
+	polygons := TraceImage(discBitmap(30, 10), OpacityColorFilledFunc)
+	fixed, err := ToFixed(polygons, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, fixed, len(polygons)) {
+		// Traced vertices are on the half-pixel grid, so they snap exactly
+		assert.Equal(t, int64(4*polygons[0].SignedArea()*2), fixed[0].DoubleSignedArea())
+		assert.InDelta(t, polygons[0].SignedArea(), fixed[0].Polygon(2).SignedArea(), 1e-9)
+	}
+
+	// Vertices that snap together or onto a straight line are removed
+	polygon := Polygon{{0, 0}, {1.1, 0}, {2, 0.1}, {2.9, -0.1}, {3, 2}, {0, 2}}
+	fixed, err = ToFixed([]Polygon{polygon}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []FixedPolygon{{{0, 0}, {3, 0}, {3, 2}, {0, 2}}}, fixed)
+
+	// Polygons which snap onto each other are reported
+	a := squarePolygon(0, 0, 1)
+	b := a.Translate(1.2, 0)
+	fixed, err = ToFixed([]Polygon{a, b}, 1)
+	if assert.Error(t, err) {
+		snapError := err.(*SnapError)
+		assert.Equal(t, ViolationIntersection, snapError.Violations[0].Kind)
+	}
+	assert.Len(t, fixed, 2)
+	_, err = ToFixed([]Polygon{a, b}, 10)
+	assert.NoError(t, err)
+}
@@ -1,21 +1,18 @@
 module github.com/osuushi/simpletrace
 
-go 1.17
+go 1.18
 
 require (
 	github.com/kr/pretty v0.3.0
 	github.com/lithammer/dedent v1.1.0
+	github.com/stretchr/testify v1.8.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/fogleman/gg v1.3.0 // indirect
-	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/kr/text v0.2.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rogpeppe/go-internal v1.6.1 // indirect
 	github.com/stretchr/objx v0.4.0 // indirect
-	github.com/stretchr/testify v1.8.0 // indirect
-	golang.org/x/image v0.0.0-20220617043117-41969df76e82 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
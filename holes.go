@@ -0,0 +1,92 @@
+package simpletrace
+
+// What to do with holes in a trace result, for targets that can't represent
+// them
+type HoleMode uint8
+
+const (
+	// Keep holes as separate polygons, wound the other way from filled polygons
+	HolesKeep = HoleMode(iota)
+	// Drop the hole polygons, so that the filled polygons around them cover
+	// them. Islands inside holes are kept, overlapping the polygons around them.
+	HolesDrop
+	// Fill holes in, dropping them along with any islands inside them, which
+	// are now part of the filled area. This gives the same result as tracing
+	// with FillHolesFilter.
+	HolesFill
+	// Join each hole to the filled polygon around it with a zero width keyhole
+	// cut, so that every shape is a single ring. Islands inside holes become
+	// rings of their own. The cut runs along the same line in both directions,
+	// so bridged rings touch themselves there, and Validate reports them as
+	// self-intersecting.
+	HolesBridge
+)
+
+// Apply a hole mode to a trace result in the default winding convention. The
+// polygons that are kept stay in their original order.
+func applyHoleMode(polygons []Polygon, mode HoleMode) []Polygon {
+	if mode == HolesKeep {
+		return polygons
+	}
+
+	keep := make([]bool, len(polygons))
+	holes := make(map[int][]Polygon)
+	var visit func(nodes []*polygonNode)
+	visit = func(nodes []*polygonNode) {
+		for _, node := range nodes {
+			if polygons[node.index].IsHole() {
+				if mode != HolesFill {
+					visit(node.children)
+				}
+				continue
+			}
+			keep[node.index] = true
+			if mode == HolesBridge {
+				for _, child := range node.children {
+					holes[node.index] = append(holes[node.index], polygons[child.index])
+				}
+			}
+			visit(node.children)
+		}
+	}
+	visit(buildPolygonTree(polygons))
+
+	var result []Polygon
+	for i, polygon := range polygons {
+		if !keep[i] {
+			continue
+		}
+		if len(holes[i]) > 0 {
+			polygon = bridgePolygon(polygon, holes[i])
+		}
+		result = append(result, polygon)
+	}
+	return result
+}
+
+// Merge holes into the filled polygon around them, using the same keyhole
+// bridging as triangulation
+func bridgePolygon(outer Polygon, holes []Polygon) Polygon {
+	var mesh Mesh
+	addRing := func(ring Polygon) []int {
+		indices := make([]int, len(ring))
+		for i, p := range ring {
+			indices[i] = len(mesh.Vertices)
+			mesh.Vertices = append(mesh.Vertices, p)
+		}
+		return indices
+	}
+
+	ring := addRing(outer)
+	var holeRings [][]int
+	for _, hole := range holes {
+		holeRings = append(holeRings, addRing(hole))
+	}
+	ring = mesh.bridgeHoles(ring, holeRings)
+
+	bridged := make(Polygon, len(ring))
+	for i, index := range ring {
+		bridged[i] = mesh.Vertices[index]
+	}
+	return bridged
+}
@@ -0,0 +1,53 @@
+package simpletrace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHoleModes(t *testing.T) {
+	img := imageFromRows(
+		"............",
+		".XXXXXXXXXX.",
+		".X........X.",
+		".X.XX.....X.",
+		".X.XX..XX.X.",
+		".X.....XX.X.",
+		".X........X.",
+		".XXXXXXXXXX.",
+		"............",
+		".XX.........",
+		".XX.........",
+	)
+	trace := func(mode HoleMode) []Polygon {
+		return TraceImageWithOptions(img, OpacityColorFilledFunc, TraceOptions{Holes: mode})
+	}
+
+	kept := trace(HolesKeep)
+	filled, holes := countWindings(kept)
+	assert.Equal(t, 4, filled)
+	assert.Equal(t, 1, holes)
+
+	filled, holes = countWindings(trace(HolesDrop))
+	assert.Equal(t, 4, filled)
+	assert.Equal(t, 0, holes)
+
+	filled, holes = countWindings(trace(HolesFill))
+	assert.Equal(t, 2, filled)
+	assert.Equal(t, 0, holes)
+	assert.Equal(t, trace(HolesFill), TraceImageWithOptions(img, OpacityColorFilledFunc, TraceOptions{
+		Preprocess: []BitmapFilter{FillHolesFilter},
+	}))
+
+	filled, holes = countWindings(trace(HolesBridge))
+	assert.Equal(t, 4, filled)
+	assert.Equal(t, 0, holes)
+
+	// Bridging joins the frame and its hole into one ring, which covers the same
+	// pixels
+	bridged := trace(HolesBridge)
+	assert.Len(t, bridged, 4)
+	assert.InDelta(t, totalSignedArea(kept), totalSignedArea(bridged), 1e-9)
+	assert.Equal(t, RasterizeBitmap(kept, img.Bounds()), RasterizeBitmap(bridged, img.Bounds()))
+}
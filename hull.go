@@ -0,0 +1,195 @@
+package simpletrace
+
+import (
+	"math"
+	"sort"
+)
+
+// The convex hull of all the vertices of the given polygons, wound
+// counterclockwise like a filled polygon. Pass a single polygon for the hull of
+// one shape, or a whole trace result for the hull of everything.
+func ConvexHull(polygons ...Polygon) Polygon {
+	return convexHullOfPoints(hullPoints(polygons))
+}
+
+// ConvexHull, wound like a filled polygon in this winding convention
+func (c WindingConvention) ConvexHull(polygons ...Polygon) Polygon {
+	return c.convert(ConvexHull(polygons...))
+}
+
+// A concave hull of all the vertices of the given polygons, which hugs the
+// points more closely than the convex hull. It is wound counterclockwise like a
+// filled polygon.
+//
+// The hull starts out convex, and each edge is dug inward to the nearest point
+// inside it while the edge is more than concavity times longer than the
+// distance to that point. Lower concavity digs deeper; around 2 is a good
+// starting point, and infinity gives the convex hull. Edges shorter than
+// lengthThreshold are never dug into, which stops the hull from chasing noise.
+//
+// This is the "gift opening" algorithm of Park and Oh, as used by concaveman.
+func ConcaveHull(concavity, lengthThreshold float64, polygons ...Polygon) Polygon {
+	points := hullPoints(polygons)
+	hull := convexHullOfPoints(points)
+	if len(hull) < 3 {
+		return hull
+	}
+
+	onHull := make(map[Point]bool)
+	for _, p := range hull {
+		onHull[p] = true
+	}
+	var inner []Point
+	for _, p := range points {
+		if !onHull[p] {
+			inner = append(inner, p)
+		}
+	}
+
+	// Work on a linked ring so that points can be inserted as edges are dug
+	next := make(map[int]int)
+	ring := append([]Point{}, hull...)
+	for i := range hull {
+		next[i] = (i + 1) % len(hull)
+	}
+	queue := make([]int, len(hull))
+	for i := range queue {
+		queue[i] = i
+	}
+
+	for len(queue) > 0 {
+		start := queue[0]
+		queue = queue[1:]
+		end := next[start]
+		a, b := ring[start], ring[end]
+		length := a.DistanceTo(b)
+		if length <= lengthThreshold {
+			continue
+		}
+
+		// Find the closest unused point to the edge, which must be closer to it
+		// than to the neighboring edges, so that digging doesn't jump across them
+		prev := ring[previousInRing(next, start)]
+		after := ring[next[end]]
+		best := -1
+		bestDistance := math.Inf(1)
+		for i, p := range inner {
+			if onHull[p] {
+				continue
+			}
+			distance := distanceToSegment(p, a, b)
+			if distance >= bestDistance {
+				continue
+			}
+			if distanceToSegment(p, prev, a) < distance || distanceToSegment(p, b, after) < distance {
+				continue
+			}
+			best = i
+			bestDistance = distance
+		}
+		// A point on the edge splits it without changing the outline, so that
+		// the two halves can be dug separately. This happens whatever the
+		// concavity, and the extra vertex is removed at the end.
+		if best < 0 || (bestDistance > 0 && length/bestDistance <= concavity) {
+			continue
+		}
+
+		// Only dig if the new edges don't cross the rest of the hull
+		p := inner[best]
+		if hullEdgeCrosses(ring, next, start, a, p) || hullEdgeCrosses(ring, next, start, p, b) {
+			continue
+		}
+
+		ring = append(ring, p)
+		inserted := len(ring) - 1
+		next[inserted] = end
+		next[start] = inserted
+		onHull[p] = true
+		queue = append(queue, start, inserted)
+	}
+
+	var result Polygon
+	for i := 0; ; {
+		result = append(result, ring[i])
+		i = next[i]
+		if i == 0 {
+			break
+		}
+	}
+	return removeCollinearVertices(result)
+}
+
+// ConcaveHull, wound like a filled polygon in this winding convention
+func (c WindingConvention) ConcaveHull(concavity, lengthThreshold float64, polygons ...Polygon) Polygon {
+	return c.convert(ConcaveHull(concavity, lengthThreshold, polygons...))
+}
+
+func hullPoints(polygons []Polygon) []Point {
+	var points []Point
+	for _, polygon := range polygons {
+		points = append(points, polygon...)
+	}
+	return points
+}
+
+// Andrew's monotone chain algorithm. Collinear points are left out.
+func convexHullOfPoints(points []Point) Polygon {
+	sorted := append([]Point{}, points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].X < sorted[j].X || (sorted[i].X == sorted[j].X && sorted[i].Y < sorted[j].Y)
+	})
+	if len(sorted) < 3 {
+		return sorted
+	}
+
+	var hull Polygon
+	// Lower hull, then upper hull, each only turning left
+	for pass := 0; pass < 2; pass++ {
+		start := len(hull)
+		for _, p := range sorted {
+			for len(hull) >= start+2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+				hull = hull[:len(hull)-1]
+			}
+			hull = append(hull, p)
+		}
+		// The last point is the first point of the other half
+		hull = hull[:len(hull)-1]
+		for i, j := 0, len(sorted)-1; i < j; i, j = i+1, j-1 {
+			sorted[i], sorted[j] = sorted[j], sorted[i]
+		}
+	}
+	return hull
+}
+
+func distanceToSegment(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lengthSquared := dx*dx + dy*dy
+	if lengthSquared == 0 {
+		return p.DistanceTo(a)
+	}
+	t := math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/lengthSquared))
+	return p.DistanceTo(Point{a.X + dx*t, a.Y + dy*t})
+}
+
+func previousInRing(next map[int]int, i int) int {
+	for j := next[i]; ; j = next[j] {
+		if next[j] == i {
+			return j
+		}
+	}
+}
+
+// Whether the segment ab crosses any edge of the ring other than the edge
+// being replaced, which starts at skip, and the edges on either side of it
+func hullEdgeCrosses(ring []Point, next map[int]int, skip int, a, b Point) bool {
+	for i := next[skip]; i != skip; i = next[i] {
+		c, d := ring[i], ring[next[i]]
+		if c == a || c == b || d == a || d == b {
+			continue
+		}
+		if segmentsIntersect(a, b, c, d) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package simpletrace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvexHull(t *testing.T) {
+	lShape := Polygon{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}}
+	hull := ConvexHull(lShape)
+	assert.Len(t, hull, 5)
+	assert.InDelta(t, 3.5, hull.SignedArea(), 1e-9)
+
+	// Hull of a whole trace result
+	other := Polygon{{5, 5}, {6, 5}, {6, 6}}
+	hull = ConvexHull(lShape, other)
+	assert.True(t, hull.IsCounterClockwise())
+	assert.Contains(t, hull, Point{6, 6})
+	assert.Contains(t, hull, Point{0, 0})
+}
+
+func TestConcaveHull(t *testing.T) {
+	// A U shape, with plenty of points along its inside
+	var u Polygon
+	for x := 0.0; x <= 10; x++ {
+		u = append(u, Point{x, 0})
+	}
+	for y := 1.0; y <= 10; y++ {
+		u = append(u, Point{10, y})
+	}
+	for y := 10.0; y >= 2; y-- {
+		u = append(u, Point{8, y})
+	}
+	for x := 7.0; x >= 3; x-- {
+		u = append(u, Point{x, 2})
+	}
+	for y := 3.0; y <= 10; y++ {
+		u = append(u, Point{2, y})
+	}
+	for y := 10.0; y > 0; y-- {
+		u = append(u, Point{0, y})
+	}
+
+	convex := ConvexHull(u)
+	concave := ConcaveHull(2, 0, u)
+	assert.True(t, concave.IsCounterClockwise())
+	assert.Less(t, concave.Area(), convex.Area()*0.6)
+
+	// Every point is still inside or on the hull
+	for _, p := range u {
+		onEdge := false
+		for i := range concave {
+			if distanceToSegment(p, concave[i], concave[(i+1)%len(concave)]) < 1e-9 {
+				onEdge = true
+			}
+		}
+		assert.True(t, onEdge || pointInPolygon(p, concave), "%v is outside the hull", p)
+	}
+
+	// Infinite concavity gives the convex hull back
+	assert.InDelta(t, convex.Area(), ConcaveHull(1e9, 0, u).Area(), 1e-9)
+
+	// Points on a hull edge are never dug to, however high the concavity
+	withCollinear := Polygon{{0, 0}, {1, 0}, {2, 0}, {3, 0}, {3, 3}, {0, 3}}
+	assert.Len(t, ConvexHull(withCollinear), 4)
+	assert.Len(t, ConcaveHull(1e9, 0, withCollinear), 4)
+}
@@ -43,18 +43,31 @@ func yValueFromColor(c color.Color) uint8 {
 	return y
 }
 
-// Convert an image into a set of squares for
-func getSquaresForImage(img image.Image, isColorFilled IsColorFilledFunc) SquareMap {
+// Convert an image into a set of squares for the marching squares algorithm.
+// The image is classified into a bitmap, and then each filter is applied in
+// order before the squares are built.
+func getSquaresForImage(img image.Image, isColorFilled IsColorFilledFunc, filters []BitmapFilter) SquareMap {
+	bitmap := BitmapFromImage(img, isColorFilled)
+	for _, filter := range filters {
+		bitmap = filter(bitmap)
+	}
+	return getSquaresForBitmap(bitmap)
+}
+
+func getSquaresForBitmap(bitmap *Bitmap) SquareMap {
 	squares := make(SquareMap)
-	bounds := img.Bounds()
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			// Convert the alpha channel of the 2x2 square here to the corner states
-			// of the square.
+	bounds := bitmap.Bounds()
+	// Squares sit between pixel centers, so start one pixel before the bounds.
+	// That way, filled pixels on the edge of the image are surrounded by squares,
+	// and get closed paths around them.
+	for y := bounds.Min.Y - 1; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X - 1; x < bounds.Max.X; x++ {
+			// Convert the 2x2 square of pixels here to the corner states of the
+			// square.
 			corners := CornerStates(0)
 			for offsetY := 0; offsetY < 2; offsetY++ {
 				for offsetX := 0; offsetX < 2; offsetX++ {
-					if isColorFilled(img.At(x+offsetX, y+offsetY)) {
+					if bitmap.Filled(x+offsetX, y+offsetY) {
 						corners |= CornerStateForOffset(offsetX, offsetY)
 					}
 				}
@@ -0,0 +1,34 @@
+package simpletrace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Filled pixels on the top and left edges used to leave paths open, because no
+// squares were built above or to the left of the image
+func TestTraceFilledEdges(t *testing.T) {
+	polygons := TraceImage(imageFromRows("X"), OpacityColorFilledFunc)
+	if assert.Len(t, polygons, 1) {
+		assert.True(t, SignedAreaOfPolygon(polygons[0]) > 0)
+	}
+
+	polygons = TraceImage(imageFromRows(
+		"XXX",
+		"X..",
+		"X..",
+	), OpacityColorFilledFunc)
+	if assert.Len(t, polygons, 1) {
+		assert.True(t, SignedAreaOfPolygon(polygons[0]) > 0)
+	}
+
+	// Separate shapes on the edges each get their own polygon
+	filled, holes := countWindings(TraceImage(imageFromRows(
+		"X..",
+		"..X",
+		"XXX",
+	), OpacityColorFilledFunc))
+	assert.Equal(t, 2, filled)
+	assert.Equal(t, 0, holes)
+}
@@ -0,0 +1,200 @@
+package simpletrace
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// How many entries each node of a ShapeIndex holds
+const indexNodeCapacity = 8
+
+// A spatial index over shapes for fast hit testing. It is a static R-tree,
+// packed with the Sort-Tile-Recursive algorithm, so it can't be changed once it
+// is built.
+//
+// Queries return indices into the slice of shapes the index was built from.
+type ShapeIndex struct {
+	shapes []Shape
+	root   *indexNode
+}
+
+type indexNode struct {
+	bounds   Rect
+	children []*indexNode
+	shape    int // Only meaningful for leaves, which have no children
+}
+
+func NewShapeIndex(shapes []Shape) *ShapeIndex {
+	index := &ShapeIndex{shapes: shapes}
+	if len(shapes) == 0 {
+		return index
+	}
+
+	nodes := make([]*indexNode, len(shapes))
+	for i, shape := range shapes {
+		nodes[i] = &indexNode{bounds: shape.Bounds(), shape: i}
+	}
+	for len(nodes) > 1 {
+		nodes = packIndexNodes(nodes)
+	}
+	index.root = nodes[0]
+	return index
+}
+
+// Build an index from a trace result, grouping it into shapes first. For other
+// winding conventions, group the shapes with the convention's
+// ShapesFromPolygons and pass them to NewShapeIndex.
+func NewShapeIndexFromPolygons(polygons []Polygon) *ShapeIndex {
+	return NewShapeIndex(ShapesFromPolygons(polygons))
+}
+
+func (index *ShapeIndex) Shapes() []Shape {
+	return index.shapes
+}
+
+// Group nodes into parents of up to indexNodeCapacity children, by sorting them
+// into vertical slices by x, and then packing each slice by y
+func packIndexNodes(nodes []*indexNode) []*indexNode {
+	center := func(node *indexNode) Point {
+		return Point{(node.bounds.Min.X + node.bounds.Max.X) / 2, (node.bounds.Min.Y + node.bounds.Max.Y) / 2}
+	}
+	parentCount := (len(nodes) + indexNodeCapacity - 1) / indexNodeCapacity
+	sliceCount := int(math.Ceil(math.Sqrt(float64(parentCount))))
+	sliceSize := sliceCount * indexNodeCapacity
+
+	sort.SliceStable(nodes, func(i, j int) bool { return center(nodes[i]).X < center(nodes[j]).X })
+	var parents []*indexNode
+	for start := 0; start < len(nodes); start += sliceSize {
+		slice := nodes[start:minInt(start+sliceSize, len(nodes))]
+		sort.SliceStable(slice, func(i, j int) bool { return center(slice[i]).Y < center(slice[j]).Y })
+		for i := 0; i < len(slice); i += indexNodeCapacity {
+			children := slice[i:minInt(i+indexNodeCapacity, len(slice))]
+			parent := &indexNode{
+				bounds:   children[0].bounds,
+				children: append([]*indexNode{}, children...),
+			}
+			for _, child := range children[1:] {
+				parent.bounds = parent.bounds.Union(child.bounds)
+			}
+			parents = append(parents, parent)
+		}
+	}
+	return parents
+}
+
+// Visit every leaf whose bounds pass the test, pruning branches that fail it
+func (index *ShapeIndex) search(test func(Rect) bool, visit func(shape int)) {
+	if index.root == nil {
+		return
+	}
+	stack := []*indexNode{index.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !test(node.bounds) {
+			continue
+		}
+		if len(node.children) == 0 {
+			visit(node.shape)
+			continue
+		}
+		stack = append(stack, node.children...)
+	}
+}
+
+// The shapes whose filled area contains the point
+func (index *ShapeIndex) At(p Point) []int {
+	var result []int
+	index.search(func(bounds Rect) bool {
+		return bounds.Contains(p)
+	}, func(shape int) {
+		if index.shapes[shape].Contains(p) {
+			result = append(result, shape)
+		}
+	})
+	sort.Ints(result)
+	return result
+}
+
+// The shapes whose filled area overlaps the rectangle
+func (index *ShapeIndex) InRect(r Rect) []int {
+	var result []int
+	index.search(func(bounds Rect) bool {
+		return bounds.Intersects(r)
+	}, func(shape int) {
+		if shapeIntersectsRect(index.shapes[shape], r) {
+			result = append(result, shape)
+		}
+	})
+	sort.Ints(result)
+	return result
+}
+
+// The shape whose filled area is closest to the point, and the distance to it.
+// The distance is zero if the point is inside the shape. If the index is empty,
+// the shape is -1.
+func (index *ShapeIndex) Nearest(p Point) (int, float64) {
+	if index.root == nil {
+		return -1, math.Inf(1)
+	}
+
+	// Best-first search. Nodes are ordered by the distance to their bounds,
+	// which is never more than the distance to anything inside them, and leaves
+	// by their exact distance, so the first leaf to come out is the closest.
+	queue := &indexQueue{{node: index.root, distance: index.root.bounds.DistanceTo(p)}}
+	for queue.Len() > 0 {
+		entry := heap.Pop(queue).(indexQueueEntry)
+		node := entry.node
+		if len(node.children) == 0 {
+			if entry.exact {
+				return node.shape, entry.distance
+			}
+			heap.Push(queue, indexQueueEntry{node: node, distance: index.shapes[node.shape].DistanceTo(p), exact: true})
+			continue
+		}
+		for _, child := range node.children {
+			heap.Push(queue, indexQueueEntry{node: child, distance: child.bounds.DistanceTo(p)})
+		}
+	}
+	return -1, math.Inf(1)
+}
+
+type indexQueueEntry struct {
+	node     *indexNode
+	distance float64
+	exact    bool // Whether distance is to the shape itself, rather than its bounds
+}
+
+type indexQueue []indexQueueEntry
+
+func (q indexQueue) Len() int            { return len(q) }
+func (q indexQueue) Less(i, j int) bool  { return q[i].distance < q[j].distance }
+func (q indexQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *indexQueue) Push(x interface{}) { *q = append(*q, x.(indexQueueEntry)) }
+func (q *indexQueue) Pop() interface{} {
+	old := *q
+	entry := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return entry
+}
+
+// Whether the filled area of a shape overlaps a rectangle. Either an edge of the
+// shape crosses the rectangle, or one is entirely inside the other.
+func shapeIntersectsRect(shape Shape, r Rect) bool {
+	corners := []Point{r.Min, {r.Max.X, r.Min.Y}, r.Max, {r.Min.X, r.Max.Y}}
+	for _, polygon := range shape.Polygons() {
+		for i := range polygon {
+			a, b := polygon[i], polygon[(i+1)%len(polygon)]
+			if r.Contains(a) {
+				return true
+			}
+			for j := range corners {
+				if segmentsIntersect(a, b, corners[j], corners[(j+1)%4]) {
+					return true
+				}
+			}
+		}
+	}
+	return shape.Contains(r.Min)
+}
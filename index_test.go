@@ -0,0 +1,75 @@
+package simpletrace
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainsPoint(t *testing.T) {
+	outer := squarePolygon(0, 0, 10)
+	hole := squarePolygon(3, 3, 4).Reverse()
+	polygons := []Polygon{outer, hole}
+
+	assert.Equal(t, 1, outer.WindingNumber(Point{1, 1}))
+	assert.Equal(t, -1, hole.WindingNumber(Point{5, 5}))
+	assert.Equal(t, 0, outer.WindingNumber(Point{11, 5}))
+
+	assert.True(t, ContainsPoint(polygons, Point{1, 1}))
+	assert.False(t, ContainsPoint(polygons, Point{5, 5}))
+	assert.False(t, ContainsPoint(polygons, Point{-1, 5}))
+
+	shape := ShapesFromPolygons(polygons)[0]
+	assert.True(t, shape.Contains(Point{1, 1}))
+	assert.False(t, shape.Contains(Point{5, 5}))
+	assert.Equal(t, 0.0, shape.DistanceTo(Point{1, 1}))
+	assert.InDelta(t, 2, shape.DistanceTo(Point{5, 5}), 1e-9)
+	assert.InDelta(t, 3, shape.DistanceTo(Point{13, 5}), 1e-9)
+}
+
+func TestShapeIndex(t *testing.T) {
+	// A grid of squares, with a hole in the first one
+	var polygons []Polygon
+	for y := 0.0; y < 10; y++ {
+		for x := 0.0; x < 10; x++ {
+			polygons = append(polygons, squarePolygon(x*10, y*10, 8))
+		}
+	}
+	polygons = append(polygons, squarePolygon(2, 2, 4).Reverse())
+	index := NewShapeIndexFromPolygons(polygons)
+	shapes := index.Shapes()
+	assert.Len(t, shapes, 100)
+
+	find := func(p Point) int {
+		for i, shape := range shapes {
+			if shape.Outer.Contains(p) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	assert.Equal(t, []int{find(Point{55, 55})}, index.At(Point{55, 55}))
+	assert.Empty(t, index.At(Point{4, 4}))
+	assert.Empty(t, index.At(Point{9, 9}))
+
+	// A rectangle inside the hole touches nothing, but one reaching its edge does
+	assert.Empty(t, index.InRect(Rect{Point{3, 3}, Point{5, 5}}))
+	assert.Equal(t, []int{find(Point{1, 1})}, index.InRect(Rect{Point{3, 3}, Point{7, 5}}))
+	assert.Len(t, index.InRect(Rect{Point{5, 5}, Point{25, 15}}), 6)
+	// Entirely inside a shape
+	assert.Len(t, index.InRect(Rect{Point{51, 51}, Point{52, 52}}), 1)
+
+	shape, distance := index.Nearest(Point{39, 44})
+	assert.Equal(t, find(Point{45, 45}), shape)
+	assert.InDelta(t, 1, distance, 1e-9)
+	shape, distance = index.Nearest(Point{4, 4})
+	assert.Equal(t, find(Point{1, 1}), shape)
+	assert.InDelta(t, 2, distance, 1e-9)
+
+	empty := NewShapeIndex(nil)
+	shape, distance = empty.Nearest(Point{0, 0})
+	assert.Equal(t, -1, shape)
+	assert.True(t, math.IsInf(distance, 1))
+}
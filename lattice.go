@@ -0,0 +1,96 @@
+package simpletrace
+
+import (
+	"fmt"
+	"math"
+)
+
+// How many lattice steps there are in a pixel. Every point the simplifier deals
+// with is a pixel center, the midpoint of a square's side, or a squeezed corner
+// an eighth of the way along a side, so all of them lie exactly on a lattice of
+// eighths.
+const latticeScale = 8
+
+// A point on the lattice of eighths of a pixel. Geometry on the lattice is done
+// with integers, so orientation tests are exact, and give the same answers on
+// every platform however close to degenerate they are.
+type latticePoint struct {
+	X, Y int64
+}
+
+// The lattice point at p, which must already lie on the lattice. Anything else
+// is a bug in the tracer, so it panics rather than rounding.
+func latticePointFrom(p Point) latticePoint {
+	x, y := p.X*latticeScale, p.Y*latticeScale
+	if x != math.Trunc(x) || y != math.Trunc(y) {
+		panic(fmt.Sprintf("%v is not on the lattice of eighths", p))
+	}
+	return latticePoint{int64(x), int64(y)}
+}
+
+func (p latticePoint) Point() Point {
+	return Point{float64(p.X) / latticeScale, float64(p.Y) / latticeScale}
+}
+
+func (p latticePoint) sub(other latticePoint) latticePoint {
+	return latticePoint{p.X - other.X, p.Y - other.Y}
+}
+
+// The orientation of b relative to a, as the z component of their cross
+// product. It is positive if b is counterclockwise from a (in math orientation,
+// as for filled polygons), negative if clockwise, and zero if they are
+// parallel. Coordinates are at most a few million eighths across, so this can't
+// overflow.
+func latticeOrientation(a, b latticePoint) int64 {
+	return a.X*b.Y - a.Y*b.X
+}
+
+// Remove points on the line through their neighbors in a closed loop, until
+// there are none left
+func removeCollinearLatticePoints(points []latticePoint) []latticePoint {
+	for changed := true; changed && len(points) >= 3; {
+		changed = false
+		for i := 0; i < len(points) && len(points) >= 3; i++ {
+			n := len(points)
+			a, b, c := points[(i+n-1)%n], points[i], points[(i+1)%n]
+			if latticeOrientation(b.sub(a), c.sub(a)) == 0 {
+				points = append(points[:i:i], points[i+1:]...)
+				changed = true
+				i--
+			}
+		}
+	}
+	return points
+}
+
+// The directions a segment may take from its start, between two bounding
+// directions. The wedge is always narrower than a half turn, and includes its
+// bounds.
+type latticeWedge struct {
+	// The clockwise and counterclockwise bounds
+	low, high latticePoint
+}
+
+// A wedge between two directions, in either order
+func newLatticeWedge(a, b latticePoint) latticeWedge {
+	if latticeOrientation(a, b) < 0 {
+		a, b = b, a
+	}
+	return latticeWedge{a, b}
+}
+
+func (w latticeWedge) contains(direction latticePoint) bool {
+	return latticeOrientation(w.low, direction) >= 0 && latticeOrientation(direction, w.high) >= 0
+}
+
+// Narrow the wedge to the directions that are also between a and b
+func (w latticeWedge) narrow(a, b latticePoint) latticeWedge {
+	other := newLatticeWedge(a, b)
+	if latticeOrientation(w.low, other.low) > 0 {
+		w.low = other.low
+	}
+	if latticeOrientation(other.high, w.high) > 0 {
+		w.high = other.high
+	}
+	return w
+}
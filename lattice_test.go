@@ -0,0 +1,56 @@
+package simpletrace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatticeWedge(t *testing.T) {
+	wedge := newLatticeWedge(latticePoint{8, 1}, latticePoint{8, -1})
+	assert.Equal(t, latticePoint{8, -1}, wedge.low)
+	assert.True(t, wedge.contains(latticePoint{1, 0}))
+	// Bounds are included, however long the vector along them
+	assert.True(t, wedge.contains(latticePoint{8000000, 1000000}))
+	assert.False(t, wedge.contains(latticePoint{8000000, 1000001}))
+	// Directions behind the wedge are outside it
+	assert.False(t, wedge.contains(latticePoint{-1, 0}))
+
+	narrowed := wedge.narrow(latticePoint{16, 1}, latticePoint{4, -3})
+	assert.Equal(t, latticePoint{8, -1}, narrowed.low)
+	assert.Equal(t, latticePoint{16, 1}, narrowed.high)
+
+	assert.Equal(t, Point{1.5, -0.125}, latticePointFrom(Point{1.5, -0.125}).Point())
+	assert.Panics(t, func() { latticePointFrom(Point{1.5, 1.0 / 3}) })
+}
+
+func TestSqueezeCorners(t *testing.T) {
+	// Both corners move an eighth of a pixel, whichever way the side runs
+	a, b := squeezeCorners(Point{2, 3}, Point{3, 3})
+	assert.Equal(t, Point{2.125, 3}, a.Point())
+	assert.Equal(t, Point{2.875, 3}, b.Point())
+	a, b = squeezeCorners(Point{2, 3}, Point{2, 2})
+	assert.Equal(t, Point{2, 2.875}, a.Point())
+	assert.Equal(t, Point{2, 2.125}, b.Point())
+}
+
+func TestTraceShallowLine(t *testing.T) {
+	// A staircase of long steps. Each run along a step lies exactly on the edge
+	// of the wedge, and must still be traced as a single segment.
+	rows := make([]string, 4)
+	for y := range rows {
+		row := make([]byte, 48)
+		for x := range row {
+			row[x] = '.'
+			if x >= 12*y && x <= 12*y+12 {
+				row[x] = 'X'
+			}
+		}
+		rows[y] = string(row)
+	}
+	polygons := TraceImage(imageFromRows(rows...), OpacityColorFilledFunc)
+	assert.Empty(t, Validate(polygons))
+	if assert.Len(t, polygons, 1) {
+		assert.LessOrEqual(t, len(polygons[0]), 20)
+	}
+}
@@ -0,0 +1,179 @@
+package simpletrace
+
+import "image"
+
+// A set of offsets from a pixel which together make up the neighborhood used by
+// morphological operations. The origin should normally be included.
+type StructuringElement []image.Point
+
+// A square structuring element, which is 2*radius+1 pixels wide
+func SquareStructuringElement(radius int) StructuringElement {
+	var element StructuringElement
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			element = append(element, image.Point{x, y})
+		}
+	}
+	return element
+}
+
+// A disc shaped structuring element, containing every offset within radius of
+// the origin
+func DiscStructuringElement(radius int) StructuringElement {
+	var element StructuringElement
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius {
+				element = append(element, image.Point{x, y})
+			}
+		}
+	}
+	return element
+}
+
+// Grow the filled regions of the bitmap. Each filled pixel also fills the pixels
+// at the element's offsets from it, so a pixel is filled in the result if any
+// pixel in its reflected neighborhood is filled.
+func (b *Bitmap) Dilate(element StructuringElement) *Bitmap {
+	return b.dilate(element, b.Rect)
+}
+
+// Shrink the filled regions of the bitmap. A pixel is filled in the result if
+// every pixel in its neighborhood is filled. Pixels outside the bitmap count as
+// empty, just as they do when tracing, so regions touching the edge of the
+// image erode away from it.
+func (b *Bitmap) Erode(element StructuringElement) *Bitmap {
+	return b.erode(element, b.Rect)
+}
+
+// Erode and then dilate, which removes features smaller than the element
+// without shrinking the rest of the image
+func (b *Bitmap) Open(element StructuringElement) *Bitmap {
+	return b.Erode(element).Dilate(element)
+}
+
+// Dilate and then erode, which closes gaps smaller than the element without
+// growing the rest of the image
+func (b *Bitmap) Close(element StructuringElement) *Bitmap {
+	// The dilation has to be allowed to spill past the edge of the bitmap, or
+	// the erosion would pull regions touching the edge away from it.
+	padded := b.Rect.Inset(-element.radius())
+	return b.dilate(element, padded).erode(element, b.Rect)
+}
+
+// Dilate into a bitmap covering rect, which may be larger than the original
+func (b *Bitmap) dilate(element StructuringElement, rect image.Rectangle) *Bitmap {
+	result := NewBitmap(rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			for _, offset := range element {
+				if b.Filled(x-offset.X, y-offset.Y) {
+					result.Pix[result.offset(x, y)] = true
+					break
+				}
+			}
+		}
+	}
+	return result
+}
+
+// Erode into a bitmap covering rect, which may be smaller than the original
+func (b *Bitmap) erode(element StructuringElement, rect image.Rectangle) *Bitmap {
+	result := NewBitmap(rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			filled := true
+			for _, offset := range element {
+				if !b.Filled(x+offset.X, y+offset.Y) {
+					filled = false
+					break
+				}
+			}
+			result.Pix[result.offset(x, y)] = filled
+		}
+	}
+	return result
+}
+
+// The largest distance along either axis from the origin to an offset
+func (element StructuringElement) radius() int {
+	radius := 0
+	for _, offset := range element {
+		for _, d := range []int{offset.X, -offset.X, offset.Y, -offset.Y} {
+			if d > radius {
+				radius = d
+			}
+		}
+	}
+	return radius
+}
+
+// Fill every empty region which is not connected to the edge of the bitmap.
+//
+// Empty pixels are considered connected diagonally, which matches how the
+// tracer resolves saddle points, so exactly the regions which would have been
+// traced as holes are filled.
+func (b *Bitmap) FillHoles() *Bitmap {
+	// Flood the empty pixels reachable from the edge of the bitmap
+	outside := NewBitmap(b.Rect)
+	var stack []image.Point
+	visit := func(x, y int) {
+		if !(image.Point{x, y}).In(b.Rect) {
+			return
+		}
+		i := b.offset(x, y)
+		if b.Pix[i] || outside.Pix[i] {
+			return
+		}
+		outside.Pix[i] = true
+		stack = append(stack, image.Point{x, y})
+	}
+
+	for x := b.Rect.Min.X; x < b.Rect.Max.X; x++ {
+		visit(x, b.Rect.Min.Y)
+		visit(x, b.Rect.Max.Y-1)
+	}
+	for y := b.Rect.Min.Y; y < b.Rect.Max.Y; y++ {
+		visit(b.Rect.Min.X, y)
+		visit(b.Rect.Max.X-1, y)
+	}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for offsetY := -1; offsetY <= 1; offsetY++ {
+			for offsetX := -1; offsetX <= 1; offsetX++ {
+				visit(p.X+offsetX, p.Y+offsetY)
+			}
+		}
+	}
+
+	// Everything else is filled
+	result := NewBitmap(b.Rect)
+	for i := range result.Pix {
+		result.Pix[i] = !outside.Pix[i]
+	}
+	return result
+}
+
+// A preprocessing step applied to the bitmap before it is traced
+type BitmapFilter func(*Bitmap) *Bitmap
+
+func DilateFilter(element StructuringElement) BitmapFilter {
+	return func(b *Bitmap) *Bitmap { return b.Dilate(element) }
+}
+
+func ErodeFilter(element StructuringElement) BitmapFilter {
+	return func(b *Bitmap) *Bitmap { return b.Erode(element) }
+}
+
+func OpenFilter(element StructuringElement) BitmapFilter {
+	return func(b *Bitmap) *Bitmap { return b.Open(element) }
+}
+
+func CloseFilter(element StructuringElement) BitmapFilter {
+	return func(b *Bitmap) *Bitmap { return b.Close(element) }
+}
+
+var FillHolesFilter BitmapFilter = func(b *Bitmap) *Bitmap {
+	return b.FillHoles()
+}
@@ -0,0 +1,73 @@
+package simpletrace
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMorphology(t *testing.T) {
+	bitmap := BitmapFromImage(imageFromRows(
+		".......",
+		".XXX...",
+		".X.X...",
+		".XXX..X",
+		".......",
+	), OpacityColorFilledFunc)
+
+	count := func(b *Bitmap) int {
+		n := 0
+		for _, filled := range b.Pix {
+			if filled {
+				n++
+			}
+		}
+		return n
+	}
+
+	assert.Equal(t, 9, count(bitmap))
+	assert.Equal(t, 10, count(bitmap.FillHoles()))
+	assert.Equal(t, 0, count(bitmap.Erode(SquareStructuringElement(1))))
+	assert.Equal(t, 12, count(bitmap.Close(SquareStructuringElement(1))))
+	assert.Equal(t, 0, count(bitmap.Open(DiscStructuringElement(1))))
+	assert.True(t, bitmap.Dilate(DiscStructuringElement(1)).Filled(5, 3))
+	assert.False(t, bitmap.Dilate(DiscStructuringElement(1)).Filled(5, 2))
+}
+
+func TestMorphologyAsymmetricElement(t *testing.T) {
+	element := StructuringElement{{0, 0}, {1, 0}}
+
+	// Dilation spreads pixels toward the offsets
+	bitmap := BitmapFromImage(imageFromRows(".X..."), OpacityColorFilledFunc)
+	assert.True(t, bitmap.Dilate(element).Filled(2, 0))
+	assert.False(t, bitmap.Dilate(element).Filled(0, 0))
+
+	// Opening a run longer than the element leaves it as it was
+	bitmap = BitmapFromImage(imageFromRows(".XXX."), OpacityColorFilledFunc)
+	assert.Equal(t, bitmap.Pix, bitmap.Open(element).Pix)
+
+	// Opening never adds pixels, and closing never removes them
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		bitmap := randomBitmap(random, 12, 12, 0.5)
+		opened, closed := bitmap.Open(element), bitmap.Close(element)
+		for j, filled := range bitmap.Pix {
+			assert.False(t, opened.Pix[j] && !filled)
+			assert.False(t, filled && !closed.Pix[j])
+		}
+	}
+}
+
+func TestTraceWithPreprocess(t *testing.T) {
+	// Filling holes in a bitmap leaves it with no holes to trace
+	filled, holes := countWindings(TraceImageWithOptions(imageFromRows(
+		".....",
+		".XXX.",
+		".X.X.",
+		".XXX.",
+		".....",
+	), OpacityColorFilledFunc, TraceOptions{Preprocess: []BitmapFilter{FillHolesFilter}}))
+	assert.Equal(t, 1, filled)
+	assert.Equal(t, 0, holes)
+}
@@ -0,0 +1,177 @@
+package simpletrace
+
+import "math"
+
+// How the offset outline is drawn around corners that it moves away from
+type JoinType uint8
+
+const (
+	// Extend the neighboring edges until they meet, up to MiterLimit
+	JoinMiter = JoinType(iota)
+	// Draw an arc around the corner
+	JoinRound
+	// Cut the corner off square, at the offset distance from the original corner
+	JoinSquare
+)
+
+type OffsetOptions struct {
+	Join JoinType
+	// For JoinMiter, the farthest a mitered corner may reach from the original
+	// corner, as a multiple of the offset distance. Sharper corners are squared
+	// off. Defaults to 2.
+	MiterLimit float64
+	// For JoinRound, the farthest the segments approximating an arc may stray
+	// from the true arc. Defaults to 0.05.
+	ArcTolerance float64
+	// The winding convention of the polygons, which the result also uses
+	Winding WindingConvention
+}
+
+// Grow the filled regions of a trace result by delta, or shrink them if delta is
+// negative. This is useful for kerf compensation on cutters, or for adding a
+// bleed margin around stickers.
+//
+// Shapes that grow into each other are merged, holes that close up are removed,
+// and shapes that shrink away to nothing disappear. The result uses the same
+// winding convention as the input, which is the default unless options.Winding
+// says otherwise.
+func Offset(polygons []Polygon, delta float64, options OffsetOptions) []Polygon {
+	if options.MiterLimit <= 0 {
+		options.MiterLimit = 2
+	}
+	if options.ArcTolerance <= 0 {
+		options.ArcTolerance = 0.05
+	}
+	polygons = options.Winding.Convert(polygons)
+	if delta == 0 {
+		return options.Winding.Convert(Union(polygons, nil))
+	}
+
+	var raw []Polygon
+	for _, polygon := range polygons {
+		if ring := offsetRing(polygon, delta, options); len(ring) >= 3 {
+			raw = append(raw, ring)
+		}
+	}
+	// The raw rings have loops wherever the offset folds back over itself, but
+	// those loops wind the wrong way, so taking the union with the positive
+	// winding rule removes them.
+	return options.Winding.Convert(Union(raw, nil))
+}
+
+// Offset each edge of a polygon outward from its filled side, and join them
+// up. The result may cross itself.
+func offsetRing(polygon Polygon, delta float64, options OffsetOptions) Polygon {
+	// Drop repeated vertices, which have no direction
+	var points Polygon
+	for i, p := range polygon {
+		if p.DistanceTo(polygon[(i+1)%len(polygon)]) > overlayTolerance {
+			points = append(points, p)
+		}
+	}
+	n := len(points)
+	if n < 3 {
+		return nil
+	}
+
+	// Unit directions and outward normals of each edge. The filled side is on
+	// the left, so outward is on the right.
+	directions := make([]Point, n)
+	normals := make([]Point, n)
+	for i := range points {
+		a, b := points[i], points[(i+1)%n]
+		length := a.DistanceTo(b)
+		directions[i] = Point{(b.X - a.X) / length, (b.Y - a.Y) / length}
+		normals[i] = Point{directions[i].Y, -directions[i].X}
+	}
+
+	var ring Polygon
+	for i, v := range points {
+		prev := (i + n - 1) % n
+		n1, n2 := normals[prev], normals[i]
+		d1, d2 := directions[prev], directions[i]
+		sin := n1.X*n2.Y - n1.Y*n2.X
+		cos := n1.X*n2.X + n1.Y*n2.Y
+		offset1 := Point{v.X + n1.X*delta, v.Y + n1.Y*delta}
+		offset2 := Point{v.X + n2.X*delta, v.Y + n2.Y*delta}
+
+		switch {
+		case math.Abs(sin) < 1e-12 && cos > 0:
+			// Straight through
+			ring = append(ring, offset1)
+		case sin*delta < 0 && !(math.Abs(sin) < 1e-12 && cos < 0):
+			// The offset edges overlap here. Route through the original vertex, so
+			// that the overlap becomes a backward loop which the union removes.
+			ring = append(ring, offset1, v, offset2)
+		default:
+			ring = append(ring, joinCorner(v, n1, n2, d1, d2, delta, options)...)
+		}
+	}
+	return ring
+}
+
+// The points which join the offset edges on the outside of a corner at v
+func joinCorner(v, n1, n2, d1, d2 Point, delta float64, options OffsetOptions) []Point {
+	sin := n1.X*n2.Y - n1.Y*n2.X
+	cos := n1.X*n2.X + n1.Y*n2.Y
+	distance := math.Abs(delta)
+	at := func(n Point, scale float64) Point {
+		return Point{v.X + n.X*scale, v.Y + n.Y*scale}
+	}
+
+	join := options.Join
+	if cos < -1+1e-9 {
+		// The outline doubles back on itself, so there's no sensible miter or
+		// square, only an arc around the tip
+		join = JoinRound
+	} else if join == JoinMiter && math.Sqrt(2/(1+cos)) > options.MiterLimit {
+		join = JoinSquare
+	}
+
+	switch join {
+	case JoinMiter:
+		// The miter point is along the bisector of the normals
+		scale := delta / (1 + cos)
+		return []Point{{v.X + (n1.X+n2.X)*scale, v.Y + (n1.Y+n2.Y)*scale}}
+
+	case JoinSquare:
+		// Cut across the bisector at the offset distance, and find where that
+		// cut meets each offset edge
+		sign := math.Copysign(1, delta)
+		bisector := Point{n1.X + n2.X, n1.Y + n2.Y}
+		length := math.Hypot(bisector.X, bisector.Y)
+		bisector = Point{bisector.X / length * sign, bisector.Y / length * sign}
+		along := func(n, d Point) float64 {
+			return distance * (1 - (n.X*bisector.X+n.Y*bisector.Y)*sign) / (d.X*bisector.X + d.Y*bisector.Y)
+		}
+		s1, s2 := along(n1, d1), along(n2, d2)
+		a := at(n1, delta)
+		b := at(n2, delta)
+		return []Point{
+			{a.X + d1.X*s1, a.Y + d1.Y*s1},
+			{b.X + d2.X*s2, b.Y + d2.Y*s2},
+		}
+
+	default:
+		angle := math.Atan2(sin, cos)
+		if cos < -1+1e-9 {
+			angle = math.Copysign(math.Pi, delta)
+		}
+		// Each step may cut inside the arc by at most the tolerance
+		step := math.Pi / 2
+		if options.ArcTolerance < distance {
+			step = 2 * math.Acos(1-options.ArcTolerance/distance)
+		}
+		steps := int(math.Ceil(math.Abs(angle) / step))
+		if steps < 1 {
+			steps = 1
+		}
+		points := make([]Point, 0, steps+1)
+		for i := 0; i <= steps; i++ {
+			sinT, cosT := math.Sincos(angle * float64(i) / float64(steps))
+			n := Point{n1.X*cosT - n1.Y*sinT, n1.X*sinT + n1.Y*cosT}
+			points = append(points, at(n, delta))
+		}
+		return points
+	}
+}
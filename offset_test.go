@@ -0,0 +1,55 @@
+package simpletrace
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffset(t *testing.T) {
+	a := []Polygon{squarePolygon(0, 0, 2)}
+
+	assert.InDelta(t, 16, totalSignedArea(Offset(a, 1, OffsetOptions{Join: JoinMiter})), 1e-9)
+	assert.InDelta(t, 12+math.Pi, totalSignedArea(Offset(a, 1, OffsetOptions{Join: JoinRound, ArcTolerance: 0.001})), 0.01)
+	squareArea := totalSignedArea(Offset(a, 1, OffsetOptions{Join: JoinSquare}))
+	assert.Greater(t, squareArea, 12+math.Pi)
+	assert.Less(t, squareArea, 16.0)
+
+	// Shrinking
+	for _, join := range []JoinType{JoinMiter, JoinRound, JoinSquare} {
+		shrunk := Offset(a, -0.5, OffsetOptions{Join: join})
+		assert.Len(t, shrunk, 1)
+		assert.InDelta(t, 1, totalSignedArea(shrunk), 1e-9)
+		assert.Empty(t, Offset(a, -1.5, OffsetOptions{Join: join}))
+	}
+
+	// Holes shrink as the shape grows, and disappear when they close up
+	withHole := []Polygon{squarePolygon(0, 0, 10), squarePolygon(4, 4, 2).Reverse()}
+	grown := Offset(withHole, 0.5, OffsetOptions{})
+	assert.Len(t, grown, 2)
+	assert.InDelta(t, 121-1, totalSignedArea(grown), 1e-9)
+	grown = Offset(withHole, 1.5, OffsetOptions{})
+	assert.Len(t, grown, 1)
+	assert.InDelta(t, 169, totalSignedArea(grown), 1e-9)
+
+	// Shapes that grow into each other merge
+	merged := Offset([]Polygon{squarePolygon(0, 0, 2), squarePolygon(3, 0, 2)}, 1, OffsetOptions{})
+	assert.Len(t, merged, 1)
+	assert.InDelta(t, 7*4, totalSignedArea(merged), 1e-9)
+}
+
+func TestOffsetTrace(t *testing.T) {
+	polygons := TraceImage(imageFromRows(
+		"............",
+		".XXXX..XXXX.",
+		".X..X..X..X.",
+		".XXXX..XXXX.",
+		"............",
+	), OpacityColorFilledFunc)
+	grown := Offset(polygons, 1.5, OffsetOptions{Join: JoinRound})
+	filled, holes := countWindings(grown)
+	assert.Equal(t, 1, filled)
+	assert.Equal(t, 0, holes)
+	assert.Greater(t, totalSignedArea(grown), totalSignedArea(polygons))
+}
@@ -0,0 +1,79 @@
+package simpletrace
+
+import "image"
+
+// Where pixels sit in output coordinates
+type PixelConvention uint8
+
+const (
+	// Pixel centers are at whole coordinates, so pixel (x, y) covers x-0.5 to
+	// x+0.5 and y-0.5 to y+0.5. This is the tracer's own convention, since
+	// marching squares puts a square between each set of four pixel centers.
+	PixelCenters = PixelConvention(iota)
+	// Pixel edges are at whole coordinates, so pixel (x, y) covers x to x+1 and
+	// y to y+1, as in image.Image, SVG and most drawing APIs
+	PixelEdges
+)
+
+// How a trace result is mapped from pixels into the coordinates the caller
+// wants. The zero value leaves it alone, in pixel coordinates with pixel
+// centers at whole numbers and y pointing down.
+//
+// The steps are applied in the order of the fields. Whatever the transform,
+// filled polygons stay counterclockwise and holes clockwise in the new
+// coordinates, with vertex order reversed when the transform is a reflection.
+type OutputTransform struct {
+	Pixels PixelConvention
+	// Make y point up, by flipping the image within its bounds. The top edge of
+	// the image moves to where the bottom edge was, and vice versa.
+	FlipY bool
+	// The width of a pixel divided by its height, for images with non-square
+	// pixels. Horizontal coordinates are scaled by this. Defaults to 1.
+	PixelAspect float64
+	// The resolution of the image in pixels per inch, measured vertically. If
+	// this is set, the output is in inches rather than pixels. For other units,
+	// scale with Affine, for example by 25.4 for millimeters.
+	DPI float64
+	// A final transform applied to every vertex. The zero Affine, which would
+	// collapse everything to a point, is treated as the identity.
+	Affine Affine
+}
+
+// The transform that maps pixel coordinates to output coordinates, for an
+// image with the given bounds
+func (t OutputTransform) Matrix(bounds image.Rectangle) Affine {
+	m := IdentityAffine
+	// The image's top and bottom edges, in the current coordinates
+	top, bottom := float64(bounds.Min.Y)-0.5, float64(bounds.Max.Y)-0.5
+	if t.Pixels == PixelEdges {
+		m = m.Then(TranslationAffine(0.5, 0.5))
+		top, bottom = top+0.5, bottom+0.5
+	}
+	if t.FlipY {
+		m = m.Then(Affine{A: 1, E: -1, F: top + bottom})
+	}
+	if t.PixelAspect > 0 {
+		m = m.Then(ScaleAffine(t.PixelAspect, 1))
+	}
+	if t.DPI > 0 {
+		m = m.Then(ScaleAffine(1/t.DPI, 1/t.DPI))
+	}
+	if t.Affine != (Affine{}) {
+		m = m.Then(t.Affine)
+	}
+	return m
+}
+
+// Apply the output transform to a trace result from an image with the given
+// bounds
+func (t OutputTransform) apply(polygons []Polygon, bounds image.Rectangle) []Polygon {
+	if t == (OutputTransform{}) {
+		return polygons
+	}
+	m := t.Matrix(bounds)
+	transformed := make([]Polygon, len(polygons))
+	for i, polygon := range polygons {
+		transformed[i] = polygon.Transform(m)
+	}
+	return transformed
+}
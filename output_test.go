@@ -0,0 +1,44 @@
+package simpletrace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputTransform(t *testing.T) {
+	img := imageFromRows(
+		"......",
+		"......",
+		"......",
+		"..XX..",
+		"......",
+	)
+	trace := func(output OutputTransform) Polygon {
+		polygons := TraceImageWithOptions(img, OpacityColorFilledFunc, TraceOptions{Output: output})
+		if assert.Len(t, polygons, 1) {
+			assert.True(t, polygons[0].SignedArea() > 0)
+			return polygons[0]
+		}
+		return nil
+	}
+
+	assert.Equal(t, Rect{Point{1.5, 2.5}, Point{3.5, 3.5}}, trace(OutputTransform{}).Bounds())
+	assert.Equal(t, Rect{Point{2, 3}, Point{4, 4}}, trace(OutputTransform{Pixels: PixelEdges}).Bounds())
+
+	// Flipping keeps pixels on the same grid, so the filled row, which is second
+	// from the bottom, is now second from y=0
+	assert.Equal(t, Rect{Point{2, 1}, Point{4, 2}}, trace(OutputTransform{Pixels: PixelEdges, FlipY: true}).Bounds())
+	assert.Equal(t, Rect{Point{1.5, 0.5}, Point{3.5, 1.5}}, trace(OutputTransform{FlipY: true}).Bounds())
+
+	// Pixels twice as wide as they are tall, at 10 pixels per inch vertically
+	physical := trace(OutputTransform{Pixels: PixelEdges, PixelAspect: 2, DPI: 10})
+	assert.InDelta(t, 0.4, physical.Bounds().Min.X, 1e-9)
+	assert.InDelta(t, 0.8, physical.Bounds().Max.X, 1e-9)
+	assert.InDelta(t, 0.3, physical.Bounds().Min.Y, 1e-9)
+	assert.InDelta(t, 0.4, physical.Bounds().Max.Y, 1e-9)
+
+	// The affine transform comes last
+	shifted := trace(OutputTransform{Pixels: PixelEdges, Affine: TranslationAffine(-2, -3)})
+	assert.Equal(t, Rect{Point{0, 0}, Point{2, 1}}, shifted.Bounds())
+}
@@ -0,0 +1,310 @@
+package simpletrace
+
+import "math"
+
+// A closed polygon from a trace result. Filled polygons are wound
+// counterclockwise, giving them a positive signed area, and holes are wound
+// clockwise.
+type Polygon []Point
+
+// A filled polygon together with the holes directly inside it
+type Shape struct {
+	Outer Polygon
+	Holes []Polygon
+}
+
+// An axis aligned bounding box
+type Rect struct {
+	Min Point
+	Max Point
+}
+
+func (p Polygon) SignedArea() float64 {
+	return SignedAreaOfPolygon(p)
+}
+
+func (p Polygon) Area() float64 {
+	return math.Abs(p.SignedArea())
+}
+
+func (p Polygon) Perimeter() float64 {
+	perimeter := 0.0
+	for i := range p {
+		perimeter += p[i].DistanceTo(p[(i+1)%len(p)])
+	}
+	return perimeter
+}
+
+// The center of mass of the area enclosed by the polygon. Degenerate polygons
+// with no area fall back to the average of their vertices.
+func (p Polygon) Centroid() Point {
+	area, x, y := p.centroidSums()
+	if area == 0 {
+		var sum Point
+		for _, v := range p {
+			sum.X += v.X
+			sum.Y += v.Y
+		}
+		return Point{sum.X / float64(len(p)), sum.Y / float64(len(p))}
+	}
+	return Point{x / (6 * area), y / (6 * area)}
+}
+
+// The signed area, and the sums which divide by six times the area to give the
+// centroid. Sums from several polygons can be added together to find the
+// centroid of a shape with holes.
+func (p Polygon) centroidSums() (area, x, y float64) {
+	n := len(p)
+	for i := 0; i < n; i++ {
+		a, b := p[i], p[(i+1)%n]
+		c := a.X*b.Y - b.X*a.Y
+		area += c
+		x += (a.X + b.X) * c
+		y += (a.Y + b.Y) * c
+	}
+	return area / 2, x, y
+}
+
+func (p Polygon) Bounds() Rect {
+	if len(p) == 0 {
+		return Rect{}
+	}
+	bounds := Rect{p[0], p[0]}
+	for _, v := range p[1:] {
+		bounds = bounds.extend(v)
+	}
+	return bounds
+}
+
+// Whether the polygon is wound counterclockwise, meaning it has a positive
+// signed area
+func (p Polygon) IsCounterClockwise() bool {
+	return p.SignedArea() > 0
+}
+
+// Whether the polygon is a hole, which is to say it is wound clockwise. This
+// assumes the default winding convention; see WindingConvention.IsHole for
+// either convention.
+func (p Polygon) IsHole() bool {
+	return p.SignedArea() < 0
+}
+
+// A copy of the polygon with its vertices in reverse order, which turns filled
+// polygons into holes and vice versa
+func (p Polygon) Reverse() Polygon {
+	reversed := make(Polygon, len(p))
+	copy(reversed, p)
+	return reversePolygon(reversed)
+}
+
+// Apply an affine transform to every vertex. If the transform is a reflection,
+// the vertex order is reversed, so that filled polygons stay counterclockwise.
+func (p Polygon) Transform(m Affine) Polygon {
+	transformed := make(Polygon, len(p))
+	for i, v := range p {
+		transformed[i] = m.Apply(v)
+	}
+	if m.Determinant() < 0 {
+		reversePolygon(transformed)
+	}
+	return transformed
+}
+
+func (p Polygon) Translate(dx, dy float64) Polygon {
+	return p.Transform(TranslationAffine(dx, dy))
+}
+
+// All the polygons making up the shape, with the outer polygon first
+func (s Shape) Polygons() []Polygon {
+	return append([]Polygon{s.Outer}, s.Holes...)
+}
+
+// The filled area of the shape, which is the area of the outer polygon minus
+// the areas of the holes
+func (s Shape) Area() float64 {
+	area := s.Outer.Area()
+	for _, hole := range s.Holes {
+		area -= hole.Area()
+	}
+	return area
+}
+
+// The total length of the outer polygon and the holes
+func (s Shape) Perimeter() float64 {
+	perimeter := 0.0
+	for _, polygon := range s.Polygons() {
+		perimeter += polygon.Perimeter()
+	}
+	return perimeter
+}
+
+// The center of mass of the filled area of the shape
+func (s Shape) Centroid() Point {
+	var area, x, y float64
+	for _, polygon := range s.Polygons() {
+		a, px, py := polygon.centroidSums()
+		area += a
+		x += px
+		y += py
+	}
+	if area == 0 {
+		return s.Outer.Centroid()
+	}
+	return Point{x / (6 * area), y / (6 * area)}
+}
+
+func (s Shape) Bounds() Rect {
+	return s.Outer.Bounds()
+}
+
+func (s Shape) Transform(m Affine) Shape {
+	transformed := Shape{Outer: s.Outer.Transform(m)}
+	for _, hole := range s.Holes {
+		transformed.Holes = append(transformed.Holes, hole.Transform(m))
+	}
+	return transformed
+}
+
+func (s Shape) Translate(dx, dy float64) Shape {
+	return s.Transform(TranslationAffine(dx, dy))
+}
+
+// Group a trace result into shapes, pairing each filled polygon with the holes
+// directly inside it. Islands inside holes become shapes of their own.
+func ShapesFromPolygons(polygons []Polygon) []Shape {
+	return FilledCounterclockwise.ShapesFromPolygons(polygons)
+}
+
+// ShapesFromPolygons for polygons in this winding convention. The polygons in
+// the shapes are left as they are.
+func (c WindingConvention) ShapesFromPolygons(polygons []Polygon) []Shape {
+	var shapes []Shape
+	var visit func(nodes []*polygonNode)
+	visit = func(nodes []*polygonNode) {
+		for _, node := range nodes {
+			if !c.IsHole(polygons[node.index]) {
+				shape := Shape{Outer: polygons[node.index]}
+				for _, child := range node.children {
+					shape.Holes = append(shape.Holes, polygons[child.index])
+				}
+				shapes = append(shapes, shape)
+			}
+			visit(node.children)
+		}
+	}
+	visit(buildPolygonTree(polygons))
+	return shapes
+}
+
+func (r Rect) Width() float64 {
+	return r.Max.X - r.Min.X
+}
+
+func (r Rect) Height() float64 {
+	return r.Max.Y - r.Min.Y
+}
+
+// The smallest rectangle containing both the rectangle and the point
+func (r Rect) extend(p Point) Rect {
+	r.Min.X = math.Min(r.Min.X, p.X)
+	r.Min.Y = math.Min(r.Min.Y, p.Y)
+	r.Max.X = math.Max(r.Max.X, p.X)
+	r.Max.Y = math.Max(r.Max.Y, p.Y)
+	return r
+}
+
+// The number of times the polygon winds counterclockwise around the point.
+// This is 1 inside a filled polygon, -1 inside a hole, and 0 outside either.
+func (p Polygon) WindingNumber(point Point) int {
+	winding := 0
+	n := len(p)
+	for i := 0; i < n; i++ {
+		a, b := p[i], p[(i+1)%n]
+		if a.Y <= point.Y && point.Y < b.Y && cross(a, b, point) > 0 {
+			winding++
+		} else if b.Y <= point.Y && point.Y < a.Y && cross(a, b, point) < 0 {
+			winding--
+		}
+	}
+	return winding
+}
+
+// Whether the point is inside the area enclosed by the polygon, regardless of
+// whether it is filled or a hole
+func (p Polygon) Contains(point Point) bool {
+	return pointInPolygon(point, p)
+}
+
+// The distance from the point to the nearest edge of the polygon
+func (p Polygon) DistanceTo(point Point) float64 {
+	distance := math.Inf(1)
+	for i := range p {
+		distance = math.Min(distance, distanceToSegment(point, p[i], p[(i+1)%len(p)]))
+	}
+	return distance
+}
+
+// Whether the point is in the filled area of the shape, meaning it is inside
+// the outer polygon but not inside any of the holes
+func (s Shape) Contains(point Point) bool {
+	if !s.Outer.Contains(point) {
+		return false
+	}
+	for _, hole := range s.Holes {
+		if hole.Contains(point) {
+			return false
+		}
+	}
+	return true
+}
+
+// The distance from the point to the filled area of the shape, which is zero if
+// the shape contains the point
+func (s Shape) DistanceTo(point Point) float64 {
+	if s.Contains(point) {
+		return 0
+	}
+	distance := s.Outer.DistanceTo(point)
+	for _, hole := range s.Holes {
+		distance = math.Min(distance, hole.DistanceTo(point))
+	}
+	return distance
+}
+
+// Whether the point is in the filled area of a trace result, using the winding
+// numbers of all of the polygons, so that holes are respected
+func ContainsPoint(polygons []Polygon, point Point) bool {
+	return FilledCounterclockwise.ContainsPoint(polygons, point)
+}
+
+// ContainsPoint for polygons in this winding convention
+func (c WindingConvention) ContainsPoint(polygons []Polygon, point Point) bool {
+	winding := 0
+	for _, polygon := range polygons {
+		winding += polygon.WindingNumber(point)
+	}
+	if c == FilledClockwise {
+		winding = -winding
+	}
+	return winding > 0
+}
+
+func (r Rect) Contains(p Point) bool {
+	return r.Min.X <= p.X && p.X <= r.Max.X && r.Min.Y <= p.Y && p.Y <= r.Max.Y
+}
+
+func (r Rect) Intersects(other Rect) bool {
+	return r.Min.X <= other.Max.X && other.Min.X <= r.Max.X && r.Min.Y <= other.Max.Y && other.Min.Y <= r.Max.Y
+}
+
+// The smallest rectangle containing both rectangles
+func (r Rect) Union(other Rect) Rect {
+	return r.extend(other.Min).extend(other.Max)
+}
+
+// The distance from the point to the nearest point in the rectangle
+func (r Rect) DistanceTo(p Point) float64 {
+	dx := math.Max(0, math.Max(r.Min.X-p.X, p.X-r.Max.X))
+	dy := math.Max(0, math.Max(r.Min.Y-p.Y, p.Y-r.Max.Y))
+	return math.Hypot(dx, dy)
+}
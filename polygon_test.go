@@ -0,0 +1,62 @@
+package simpletrace
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolygonGeometry(t *testing.T) {
+	square := Polygon{{0, 0}, {4, 0}, {4, 4}, {0, 4}}
+	hole := Polygon{{1, 1}, {1, 2}, {2, 2}, {2, 1}}
+
+	assert.Equal(t, 16.0, square.Area())
+	assert.Equal(t, 16.0, square.Perimeter())
+	assert.Equal(t, Point{2, 2}, square.Centroid())
+	assert.Equal(t, Rect{Point{0, 0}, Point{4, 4}}, square.Bounds())
+	assert.True(t, square.IsCounterClockwise())
+	assert.True(t, hole.IsHole())
+	assert.True(t, square.Reverse().IsHole())
+
+	shape := Shape{Outer: square, Holes: []Polygon{hole}}
+	assert.Equal(t, 15.0, shape.Area())
+	assert.Equal(t, 20.0, shape.Perimeter())
+	// Removing the hole pulls the centroid away from it
+	centroid := shape.Centroid()
+	assert.InDelta(t, 2+0.5/15, centroid.X, 1e-9)
+	assert.InDelta(t, 2+0.5/15, centroid.Y, 1e-9)
+
+	// Reflections keep filled polygons counterclockwise
+	flipped := shape.Transform(ScaleAffine(1, -1))
+	assert.True(t, flipped.Outer.IsCounterClockwise())
+	assert.True(t, flipped.Holes[0].IsHole())
+
+	moved := square.Translate(1, 2)
+	assert.Equal(t, Point{1, 2}, moved[0])
+
+	rotated := square.Transform(RotationAffine(math.Pi / 2).Then(TranslationAffine(4, 0)))
+	assert.InDelta(t, 0, rotated.Bounds().Min.X, 1e-9)
+	assert.InDelta(t, 16, rotated.SignedArea(), 1e-9)
+}
+
+func TestShapesFromPolygons(t *testing.T) {
+	img := imageFromRows(
+		".........",
+		".XXXXXXX.",
+		".X.....X.",
+		".X..X..X.",
+		".X.....X.",
+		".XXXXXXX.",
+		".........",
+		"...XXX...",
+		".........",
+	)
+	shapes := ShapesFromPolygons(TraceImage(img, OpacityColorFilledFunc))
+	assert.Len(t, shapes, 3)
+	holes := 0
+	for _, shape := range shapes {
+		holes += len(shape.Holes)
+	}
+	assert.Equal(t, 1, holes)
+}
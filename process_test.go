@@ -1,31 +1,53 @@
 package simpletrace
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestBaselineToXAxis(t *testing.T) {
-	cases := []Point{
-		{.3, .5},
-		{-.3, .8},
-		{7, -5},
-		{0, -5},
-		{0, 2},
-		{3, 0},
-		{-10, 0},
+func TestTraceHasNoCollinearVertices(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		bitmap := randomBitmap(random, 1+random.Intn(24), 1+random.Intn(24), random.Float64())
+		for _, polygon := range TraceImage(bitmap, OpacityColorFilledFunc) {
+			n := len(polygon)
+			for j, p := range polygon {
+				if !assert.NotZero(t, cross(polygon[(j+n-1)%n], p, polygon[(j+1)%n]), "bitmap %d vertex %v", i, p) {
+					return
+				}
+			}
+		}
 	}
+}
 
-	for _, c := range cases {
-		actual := baselineToXAxis(c).multiply(c)
-		assert.InDeltaf(t, 0, actual.Y, 0.00001, "expected (%.2f, %.2f) to rotate to the x axis, but got (%.2f, %.2f)", c.X, c.Y, actual.X, actual.Y)
-		assert.Greater(t, actual.X, 0.0, "expected %v to be on the positive x axis, but got (%.2f, %.2f)", c, actual.X, actual.Y)
+func TestTraceIsRepeatable(t *testing.T) {
+	// A box with a gap cut diagonally across one corner
+	box := imageFromRows(
+		".......",
+		"..XXXX.",
+		".X...X.",
+		".X...X.",
+		".X...X.",
+		".XXXXX.",
+		".......",
+	)
+	expected := TraceImage(box, OpacityColorFilledFunc)
+	for i := 0; i < 50; i++ {
+		if !assert.Equal(t, expected, TraceImage(box, OpacityColorFilledFunc)) {
+			return
+		}
+	}
 
-		// Invert the matrix to get the rotation in the other direction
-		inverse := baselineToXAxis(c).invert()
-		actual = inverse.multiply(actual)
-		assert.InDeltaf(t, c.X, actual.X, 0.00001, "expected %v to be rotated back to %v, but got (%.2f, %.2f)", c, c, actual.X, actual.Y)
-		assert.InDeltaf(t, c.Y, actual.Y, 0.00001, "expected %v to be rotated back to %v, but got (%.2f, %.2f)", c, c, actual.X, actual.Y)
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		bitmap := randomBitmap(random, 1+random.Intn(24), 1+random.Intn(24), random.Float64())
+		expected := TraceImage(bitmap, OpacityColorFilledFunc)
+		for j := 0; j < 10; j++ {
+			if !assert.Equal(t, expected, TraceImage(bitmap, OpacityColorFilledFunc), "bitmap %d", i) {
+				return
+			}
+		}
 	}
 }
@@ -0,0 +1,173 @@
+package simpletrace
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+)
+
+// How many scanlines are sampled within each row of pixels when anti-aliasing.
+// Coverage across each scanline is exact, so this only limits vertical
+// precision.
+const antiAliasSubsamples = 16
+
+type RasterizeOptions struct {
+	// How many output pixels there are for each pixel of the traced image.
+	// Defaults to 1.
+	Scale float64
+	// Shade pixels on the outline by how much of them is covered, rather than
+	// filling only those whose centers are covered
+	AntiAlias bool
+	// The winding convention of the polygons
+	Winding WindingConvention
+}
+
+// Render the filled area of polygons into an alpha mask with the given bounds.
+// The pixel at (x, y) in the traced image covers the square from (x, y) to
+// (x+1, y+1) in the mask at scale 1, and that square multiplied by the scale
+// otherwise. So for an image traced from bounds starting at the origin, the
+// mask's bounds would usually be the image's size multiplied by the scale.
+//
+// Rasterizing at a larger scale is a way to upscale a mask without blurring or
+// blocky edges.
+func RasterizeAlpha(polygons []Polygon, r image.Rectangle, options RasterizeOptions) *image.Alpha {
+	polygons = options.Winding.Convert(polygons)
+	mask := image.NewAlpha(r)
+	scale := options.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+
+	// Move to output coordinates, where pixel centers are at half integers
+	scaled := make([]Polygon, len(polygons))
+	for i, polygon := range polygons {
+		scaled[i] = make(Polygon, len(polygon))
+		for j, p := range polygon {
+			scaled[i][j] = Point{(p.X + 0.5) * scale, (p.Y + 0.5) * scale}
+		}
+	}
+
+	coverage := make([]float64, r.Dx())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		if !options.AntiAlias {
+			fillSpans(scaled, float64(y)+0.5, func(start, end float64) {
+				first := maxInt(r.Min.X, int(math.Ceil(start-0.5)))
+				last := minInt(r.Max.X-1, int(math.Floor(end-0.5)))
+				for x := first; x <= last; x++ {
+					mask.Pix[mask.PixOffset(x, y)] = 0xff
+				}
+			})
+			continue
+		}
+
+		for i := range coverage {
+			coverage[i] = 0
+		}
+		for sample := 0; sample < antiAliasSubsamples; sample++ {
+			sampleY := float64(y) + (float64(sample)+0.5)/antiAliasSubsamples
+			fillSpans(scaled, sampleY, func(start, end float64) {
+				start = math.Max(start, float64(r.Min.X))
+				end = math.Min(end, float64(r.Max.X))
+				for x := int(math.Floor(start)); float64(x) < end; x++ {
+					covered := math.Min(end, float64(x+1)) - math.Max(start, float64(x))
+					coverage[x-r.Min.X] += covered / antiAliasSubsamples
+				}
+			})
+		}
+		for i, amount := range coverage {
+			mask.Pix[mask.PixOffset(r.Min.X+i, y)] = uint8(math.Round(math.Min(amount, 1) * 0xff))
+		}
+	}
+	return mask
+}
+
+// Paint the filled area of polygons onto an image in the given color, using the
+// coordinates described in RasterizeAlpha. The polygons are rasterized over the
+// whole of the destination's bounds.
+func DrawPolygons(dst draw.Image, polygons []Polygon, c color.Color, options RasterizeOptions) {
+	bounds := dst.Bounds()
+	mask := RasterizeAlpha(polygons, bounds, options)
+	draw.DrawMask(dst, bounds, image.NewUniform(c), image.Point{}, mask, bounds.Min, draw.Over)
+}
+
+// Rasterize polygons onto a pixel grid in trace coordinates, where the pixel at
+// (x, y) is centered on the point (x, y). A pixel is filled if its center is in
+// the filled area of the polygons. Rasterizing a trace result onto the bounds
+// of the bitmap it was traced from reproduces the bitmap, give or take the
+// pixels that simplification cuts across.
+func RasterizeBitmap(polygons []Polygon, r image.Rectangle) *Bitmap {
+	return FilledCounterclockwise.RasterizeBitmap(polygons, r)
+}
+
+// RasterizeBitmap for polygons in this winding convention
+func (c WindingConvention) RasterizeBitmap(polygons []Polygon, r image.Rectangle) *Bitmap {
+	polygons = c.Convert(polygons)
+	bitmap := NewBitmap(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		fillSpans(polygons, float64(y), func(start, end float64) {
+			first := maxInt(r.Min.X, int(math.Floor(start))+1)
+			last := minInt(r.Max.X-1, int(math.Ceil(end))-1)
+			for x := first; x <= last; x++ {
+				bitmap.Pix[bitmap.offset(x, y)] = true
+			}
+		})
+	}
+	return bitmap
+}
+
+type scanlineCrossing struct {
+	x     float64
+	delta int // How the winding number changes when crossing left to right
+}
+
+// Call fill for each span of the horizontal line at y that is in the filled area
+// of the polygons, from left to right. Spans are open, so fill is never called
+// with start == end.
+//
+// Edges are counted from their lower endpoint up to but not including their
+// upper one, just like in Polygon.WindingNumber, so a line through a vertex
+// crosses the outline once rather than twice.
+func fillSpans(polygons []Polygon, y float64, fill func(start, end float64)) {
+	var crossings []scanlineCrossing
+	for _, polygon := range polygons {
+		n := len(polygon)
+		for i := 0; i < n; i++ {
+			a, b := polygon[i], polygon[(i+1)%n]
+			var delta int
+			switch {
+			case a.Y <= y && y < b.Y:
+				delta = -1
+			case b.Y <= y && y < a.Y:
+				delta = 1
+			default:
+				continue
+			}
+			x := a.X + (y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+			crossings = append(crossings, scanlineCrossing{x, delta})
+		}
+	}
+	sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+	// Points left of every crossing are outside everything. Each upward edge adds
+	// one to the winding of points on its left, so crossing it takes one away.
+	winding := 0
+	var start float64
+	for i := 0; i < len(crossings); {
+		x := crossings[i].x
+		wasFilled := winding > 0
+		// Crossings at the same point are taken together, so that edges which
+		// cancel out, like the two sides of a keyhole cut, don't split a span
+		for ; i < len(crossings) && crossings[i].x == x; i++ {
+			winding += crossings[i].delta
+		}
+		if filled := winding > 0; filled != wasFilled {
+			if filled {
+				start = x
+			} else if x > start {
+				fill(start, x)
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package simpletrace
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRasterizeBitmap(t *testing.T) {
+	outer := squarePolygon(0.5, 0.5, 5)
+	hole := squarePolygon(2.5, 2.5, 1).Reverse()
+	bitmap := RasterizeBitmap([]Polygon{outer, hole}, image.Rect(0, 0, 8, 8))
+
+	count := 0
+	for _, filled := range bitmap.Pix {
+		if filled {
+			count++
+		}
+	}
+	assert.Equal(t, 24, count)
+	assert.True(t, bitmap.Filled(1, 1))
+	assert.True(t, bitmap.Filled(5, 5))
+	assert.False(t, bitmap.Filled(3, 3))
+	assert.False(t, bitmap.Filled(0, 0))
+	assert.False(t, bitmap.Filled(6, 6))
+}
+
+func TestRasterizeAlpha(t *testing.T) {
+	square := Polygon{{-0.5, -0.5}, {1.5, -0.5}, {1.5, 1.5}, {-0.5, 1.5}}
+	mask := RasterizeAlpha([]Polygon{square}, image.Rect(0, 0, 4, 4), RasterizeOptions{})
+	assert.Equal(t, uint8(0xff), mask.AlphaAt(0, 0).A)
+	assert.Equal(t, uint8(0xff), mask.AlphaAt(1, 1).A)
+	assert.Equal(t, uint8(0), mask.AlphaAt(2, 2).A)
+
+	// Scaled up, each traced pixel becomes a block of pixels
+	mask = RasterizeAlpha([]Polygon{square}, image.Rect(0, 0, 16, 16), RasterizeOptions{Scale: 4})
+	assert.Equal(t, uint8(0xff), mask.AlphaAt(7, 7).A)
+	assert.Equal(t, uint8(0), mask.AlphaAt(8, 8).A)
+
+	// Anti-aliasing shades pixels by coverage
+	half := squarePolygon(0, 0, 2)
+	mask = RasterizeAlpha([]Polygon{half}, image.Rect(0, 0, 4, 4), RasterizeOptions{AntiAlias: true})
+	assert.InDelta(t, 0xff, mask.AlphaAt(1, 1).A, 1)
+	assert.InDelta(t, 0x80, mask.AlphaAt(0, 1).A, 1)
+	assert.InDelta(t, 0x40, mask.AlphaAt(0, 0).A, 1)
+	assert.Equal(t, uint8(0), mask.AlphaAt(3, 3).A)
+}
+
+func TestRasterizeRoundTrip(t *testing.T) {
+	bitmap := discBitmap(40, 15)
+	polygons := TraceImage(bitmap, OpacityColorFilledFunc)
+	mask := RasterizeAlpha(polygons, bitmap.Rect, RasterizeOptions{})
+	rasterized := RasterizeBitmap(polygons, bitmap.Rect)
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			assert.Equal(t, rasterized.Filled(x, y), mask.AlphaAt(x, y).A == 0xff)
+		}
+	}
+
+	// The total coverage when anti-aliased is the area of the polygons
+	mask = RasterizeAlpha(polygons, image.Rect(0, 0, 80, 80), RasterizeOptions{Scale: 2, AntiAlias: true})
+	total := 0.0
+	for _, alpha := range mask.Pix {
+		total += float64(alpha) / 0xff
+	}
+	area := 0.0
+	for _, polygon := range polygons {
+		area += polygon.SignedArea()
+	}
+	assert.InEpsilon(t, area*4, total, 0.01)
+}
+
+func TestDrawPolygons(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	square := Polygon{{-0.5, -0.5}, {1.5, -0.5}, {1.5, 1.5}, {-0.5, 1.5}}
+	DrawPolygons(img, []Polygon{square}, color.RGBA{0xff, 0, 0, 0xff}, RasterizeOptions{})
+	assert.Equal(t, color.RGBA{0xff, 0, 0, 0xff}, img.RGBAAt(1, 1))
+	assert.Equal(t, color.RGBA{}, img.RGBAAt(3, 3))
+}
@@ -0,0 +1,88 @@
+package simpletrace
+
+import "math"
+
+// Clean up polygons so that strict consumers such as CAD tools will accept
+// them. Repeated vertices, collinear vertices and zero-width spikes are
+// removed, self-intersecting polygons are split into simple ones, and every
+// polygon is wound counterclockwise if it is filled and clockwise if it is a
+// hole.
+//
+// A vertex is removed when the triangle it forms with its neighbors is
+// narrower than tolerance, which covers repeated vertices, vertices on the line
+// between their neighbors, and spikes that double back on themselves. A
+// tolerance of zero or less only removes exact degeneracies.
+//
+// Polygons are first rewound by how deeply they are nested, so holes which were
+// wound the wrong way still come out as holes. Then regions covered by a
+// polygon more than once, such as where a polygon crosses itself, are filled.
+// The polygons in the result are simple, but separate polygons may touch at a
+// vertex, as the two halves of a bowtie do.
+func RepairPolygons(polygons []Polygon, tolerance float64) []Polygon {
+	return FilledCounterclockwise.RepairPolygons(polygons, tolerance)
+}
+
+// RepairPolygons, winding the result in this winding convention. The input is
+// rewound by nesting depth, so its own winding doesn't matter.
+func (c WindingConvention) RepairPolygons(polygons []Polygon, tolerance float64) []Polygon {
+	if tolerance < overlayTolerance {
+		tolerance = overlayTolerance
+	}
+
+	var cleaned []Polygon
+	for _, polygon := range polygons {
+		polygon = removeNarrowVertices(polygon, tolerance)
+		if len(polygon) >= 3 && polygon.SignedArea() != 0 {
+			cleaned = append(cleaned, polygon)
+		}
+	}
+
+	// Wind each polygon by its depth, so that the nonzero fill rule gives the
+	// intended result: filled polygons contribute +1, and holes cancel them out
+	var orient func(nodes []*polygonNode, depth int)
+	orient = func(nodes []*polygonNode, depth int) {
+		for _, node := range nodes {
+			polygon := cleaned[node.index]
+			if (polygon.SignedArea() > 0) != (depth%2 == 0) {
+				cleaned[node.index] = polygon.Reverse()
+			}
+			orient(node.children, depth+1)
+		}
+	}
+	orient(buildPolygonTree(cleaned), 0)
+
+	overlaid := overlay(cleaned, nil, nonZeroWinding, func(inA, inB bool) bool {
+		return inA
+	})
+
+	// Splitting edges can leave new vertices very close to existing ones
+	var result []Polygon
+	for _, polygon := range overlaid {
+		polygon = removeNarrowVertices(polygon, tolerance)
+		if len(polygon) >= 3 && polygon.SignedArea() != 0 {
+			result = append(result, polygon)
+		}
+	}
+	return c.Convert(result)
+}
+
+// Remove vertices where the triangle formed with their neighbors is narrower
+// than tolerance, until there are none left
+func removeNarrowVertices(polygon Polygon, tolerance float64) Polygon {
+	polygon = append(Polygon(nil), polygon...)
+	for changed := true; changed && len(polygon) >= 3; {
+		changed = false
+		for i := 0; i < len(polygon) && len(polygon) >= 3; i++ {
+			n := len(polygon)
+			a, b, c := polygon[(i+n-1)%n], polygon[i], polygon[(i+1)%n]
+			// The height of the triangle over its longest side
+			longest := math.Max(a.DistanceTo(b), math.Max(b.DistanceTo(c), c.DistanceTo(a)))
+			if longest <= tolerance || math.Abs(cross(a, b, c)) <= tolerance*longest {
+				polygon = append(polygon[:i:i], polygon[i+1:]...)
+				changed = true
+				i--
+			}
+		}
+	}
+	return polygon
+}
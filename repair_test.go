@@ -0,0 +1,65 @@
+package simpletrace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepairPolygons(t *testing.T) {
+	// Repeated and collinear vertices are removed
+	square := Polygon{{0, 0}, {1, 0}, {1, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 1}}
+	repaired := RepairPolygons([]Polygon{square}, 0)
+	if assert.Len(t, repaired, 1) {
+		assert.Len(t, repaired[0], 4)
+		assert.InDelta(t, 4, repaired[0].SignedArea(), 1e-9)
+	}
+
+	// So is a spike that doubles back on itself, and one that nearly does
+	spiky := Polygon{{0, 0}, {2, 0}, {2, 1}, {5, 1}, {2, 1}, {2, 2}, {1, 2}, {1, 5}, {1.001, 2}, {0, 2}}
+	repaired = RepairPolygons([]Polygon{spiky}, 0.01)
+	if assert.Len(t, repaired, 1) {
+		assert.Len(t, repaired[0], 4)
+		assert.InDelta(t, 4, repaired[0].SignedArea(), 0.01)
+		assert.Empty(t, Validate(repaired))
+	}
+
+	// A bowtie is split into two counterclockwise triangles, even if it starts
+	// out mostly clockwise
+	bowtie := Polygon{{0, 0}, {2, 2}, {2, 0}, {0, 3}}
+	repaired = RepairPolygons([]Polygon{bowtie}, 0)
+	if assert.Len(t, repaired, 2) {
+		for _, polygon := range repaired {
+			assert.Len(t, polygon, 3)
+			assert.True(t, polygon.SignedArea() > 0)
+			assert.Empty(t, Validate([]Polygon{polygon}))
+		}
+		assert.InDelta(t, 1.2*3/2+0.8*2/2, totalSignedArea(repaired), 1e-9)
+	}
+
+	// Holes wound the wrong way are turned around
+	outer := squarePolygon(0, 0, 10)
+	hole := squarePolygon(3, 3, 4)
+	repaired = RepairPolygons([]Polygon{outer, hole}, 0)
+	assert.Empty(t, Validate(repaired))
+	assert.InDelta(t, 100-16, totalSignedArea(repaired), 1e-9)
+
+	// So are outer polygons
+	repaired = RepairPolygons([]Polygon{outer.Reverse(), hole}, 0)
+	assert.Empty(t, Validate(repaired))
+	assert.InDelta(t, 100-16, totalSignedArea(repaired), 1e-9)
+
+	// The result can be wound in the other convention
+	repaired = FilledClockwise.RepairPolygons([]Polygon{outer, hole}, 0)
+	assert.Empty(t, FilledClockwise.Validate(repaired))
+	assert.InDelta(t, -(100 - 16), totalSignedArea(repaired), 1e-9)
+
+	// Traces are already clean, so they come through unchanged
+	polygons := TraceImage(discBitmap(40, 15), OpacityColorFilledFunc)
+	repaired = RepairPolygons(polygons, 0)
+	if assert.Len(t, repaired, len(polygons)) {
+		assert.Len(t, repaired[0], len(polygons[0]))
+		assert.InDelta(t, totalSignedArea(polygons), totalSignedArea(repaired), 1e-9)
+		assert.Empty(t, Validate(repaired))
+	}
+}
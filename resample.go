@@ -0,0 +1,191 @@
+package simpletrace
+
+import "math"
+
+// Resample a polygon so that its vertices are evenly spaced along its outline,
+// roughly spacing units apart. Spacing smaller than the existing segments
+// densifies the polygon, and larger spacing thins it out.
+//
+// If cornerAngle is positive, any vertex where the outline turns by more than
+// cornerAngle radians is kept in place, and the outline is resampled
+// separately between those corners. Otherwise, every vertex may move.
+//
+// The vertices are visited in their original order, so the winding (and
+// therefore whether the polygon is filled or a hole) is preserved.
+func ResamplePolygon(polygon Polygon, spacing float64, cornerAngle float64) Polygon {
+	if len(polygon) < 3 || spacing <= 0 {
+		return polygon
+	}
+
+	arcs := splitPolygonAtCorners(polygon, cornerAngle)
+	var result Polygon
+	for _, arc := range arcs {
+		count := int(math.Round(polylineLength(arc) / spacing))
+		if count < 1 {
+			count = 1
+		}
+		result = append(result, resamplePolyline(arc, count)...)
+	}
+	return ensureMinimumVertices(polygon, result)
+}
+
+// Resample a polygon so that it has exactly count evenly spaced vertices.
+//
+// If cornerAngle is positive, corners are preserved as in ResamplePolygon, and
+// the remaining vertices are shared among the arcs between corners in
+// proportion to their length. If there are more corners than count, only the
+// corners are returned.
+func ResamplePolygonToCount(polygon Polygon, count int, cornerAngle float64) Polygon {
+	if len(polygon) < 3 || count < 3 {
+		return polygon
+	}
+
+	arcs := splitPolygonAtCorners(polygon, cornerAngle)
+	if len(arcs) >= count {
+		// Every arc starts at a corner, so this is just the corners
+		var result Polygon
+		for _, arc := range arcs {
+			result = append(result, arc[0])
+		}
+		return result
+	}
+
+	// Every arc needs at least one segment. Hand out the rest by length,
+	// largest remainder first, so the total comes out exactly right.
+	lengths := make([]float64, len(arcs))
+	total := 0.0
+	for i, arc := range arcs {
+		lengths[i] = polylineLength(arc)
+		total += lengths[i]
+	}
+	if total == 0 {
+		// Every vertex is in the same place, so there is nothing to space out
+		return polygon
+	}
+
+	counts := make([]int, len(arcs))
+	remainders := make([]float64, len(arcs))
+	spare := count - len(arcs)
+	assigned := 0
+	for i := range arcs {
+		share := float64(spare) * lengths[i] / total
+		counts[i] = 1 + int(share)
+		remainders[i] = share - math.Floor(share)
+		assigned += counts[i]
+	}
+	for ; assigned < count; assigned++ {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		counts[best]++
+		remainders[best] = -1
+	}
+
+	var result Polygon
+	for i, arc := range arcs {
+		result = append(result, resamplePolyline(arc, counts[i])...)
+	}
+	return result
+}
+
+// Resample every polygon in a trace result with ResamplePolygon
+func ResamplePolygons(polygons []Polygon, spacing float64, cornerAngle float64) []Polygon {
+	result := make([]Polygon, len(polygons))
+	for i, polygon := range polygons {
+		result[i] = ResamplePolygon(polygon, spacing, cornerAngle)
+	}
+	return result
+}
+
+// Split a closed polygon into open arcs which each start and end at a corner.
+// The end of each arc is the start of the next. If there are no corners, the
+// whole polygon is returned as a single arc that starts and ends at the first
+// vertex.
+func splitPolygonAtCorners(polygon []Point, cornerAngle float64) [][]Point {
+	n := len(polygon)
+	var corners []int
+	if cornerAngle > 0 {
+		for i := range polygon {
+			if turningAngle(polygon[(i+n-1)%n], polygon[i], polygon[(i+1)%n]) > cornerAngle {
+				corners = append(corners, i)
+			}
+		}
+	}
+	if len(corners) == 0 {
+		corners = []int{0}
+	}
+
+	arcs := make([][]Point, len(corners))
+	for c, start := range corners {
+		end := corners[(c+1)%len(corners)]
+		if end <= start {
+			end += n
+		}
+		arc := make([]Point, 0, end-start+1)
+		for i := start; i <= end; i++ {
+			arc = append(arc, polygon[i%n])
+		}
+		arcs[c] = arc
+	}
+	return arcs
+}
+
+// The absolute angle in radians that the outline turns through at b
+func turningAngle(a, b, c Point) float64 {
+	inX, inY := b.X-a.X, b.Y-a.Y
+	outX, outY := c.X-b.X, c.Y-b.Y
+	return math.Abs(math.Atan2(inX*outY-inY*outX, inX*outX+inY*outY))
+}
+
+func polylineLength(polyline []Point) float64 {
+	length := 0.0
+	for i := 1; i < len(polyline); i++ {
+		length += polyline[i-1].DistanceTo(polyline[i])
+	}
+	return length
+}
+
+// Place count evenly spaced points along an open polyline. The first point of
+// the polyline is included, but the last is not, so that consecutive arcs can
+// be joined without duplicating their shared endpoints.
+func resamplePolyline(polyline []Point, count int) []Point {
+	step := polylineLength(polyline) / float64(count)
+	result := make([]Point, 0, count)
+	result = append(result, polyline[0])
+
+	segment := 0
+	travelled := 0.0 // Distance along the polyline to the start of segment
+	for i := 1; i < count; i++ {
+		target := step * float64(i)
+		// Advance to the segment containing the target distance
+		for segment < len(polyline)-2 {
+			segmentLength := polyline[segment].DistanceTo(polyline[segment+1])
+			if travelled+segmentLength >= target {
+				break
+			}
+			travelled += segmentLength
+			segment++
+		}
+		a, b := polyline[segment], polyline[segment+1]
+		segmentLength := a.DistanceTo(b)
+		t := 0.0
+		if segmentLength > 0 {
+			t = math.Min((target-travelled)/segmentLength, 1)
+		}
+		result = append(result, Point{a.X + (b.X-a.X)*t, a.Y + (b.Y-a.Y)*t})
+	}
+	return result
+}
+
+// Resampling with a large spacing can collapse a polygon to fewer than three
+// points. In that case, fall back to a triangle spread evenly around the
+// outline, so that the result is still a polygon.
+func ensureMinimumVertices(original Polygon, resampled Polygon) Polygon {
+	if len(resampled) >= 3 {
+		return resampled
+	}
+	return ResamplePolygonToCount(original, 3, 0)
+}
@@ -0,0 +1,46 @@
+package simpletrace
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResamplePolygon(t *testing.T) {
+	square := Polygon{{0, 0}, {4, 0}, {4, 4}, {0, 4}}
+
+	// Densifying without corners gives 16 evenly spaced points
+	resampled := ResamplePolygon(square, 1, 0)
+	assert.Len(t, resampled, 16)
+	for i := range resampled {
+		next := resampled[(i+1)%len(resampled)]
+		assert.InDelta(t, 1, resampled[i].DistanceTo(next), 1e-9)
+	}
+	assert.InDelta(t, SignedAreaOfPolygon(square), SignedAreaOfPolygon(resampled), 1e-9)
+
+	// Preserving corners keeps every original vertex
+	resampled = ResamplePolygon(square, 1.5, math.Pi/4)
+	for _, corner := range square {
+		assert.Contains(t, resampled, corner)
+	}
+
+	// Thinning out never collapses below a triangle
+	assert.Len(t, ResamplePolygon(square, 100, 0), 3)
+}
+
+func TestResamplePolygonToCount(t *testing.T) {
+	square := Polygon{{0, 0}, {4, 0}, {4, 4}, {0, 4}}
+	hole := reversePolygon(Polygon{{0, 0}, {4, 0}, {4, 4}, {0, 4}})
+
+	for _, count := range []int{3, 7, 10, 33} {
+		assert.Len(t, ResamplePolygonToCount(square, count, 0), count)
+		assert.Len(t, ResamplePolygonToCount(square, count, math.Pi/4), int(math.Max(4, float64(count))))
+		assert.Less(t, SignedAreaOfPolygon(ResamplePolygonToCount(hole, count, 0)), 0.0)
+	}
+
+	// A polygon with no length has nothing to space out
+	collapsed := Polygon{{1, 1}, {1, 1}, {1, 1}}
+	assert.Equal(t, collapsed, ResamplePolygonToCount(collapsed, 5, 0))
+	assert.Equal(t, collapsed, ResamplePolygon(collapsed, 1, 0))
+}
@@ -0,0 +1,290 @@
+package simpletrace
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// A signed distance field, sampled on a grid of pixels. Each sample is the
+// distance from the pixel's center to the nearest outline, in pixels of the
+// field, and is negative inside the filled area and positive outside.
+type SDF struct {
+	Rect      image.Rectangle
+	Distances []float64
+	// How many field pixels there are for each traced pixel. See RasterizeAlpha
+	// for how coordinates are mapped between them.
+	Scale float64
+	// The largest distance the field holds. Samples farther from the outline are
+	// clamped to this.
+	Spread float64
+	// The winding convention of polygons traced from the field
+	Winding WindingConvention
+}
+
+type SDFOptions struct {
+	// How many field pixels there are for each traced pixel. Defaults to 1.
+	Scale float64
+	// The largest distance to measure, in field pixels. This is also the
+	// distance that maps to black or white when the field is encoded as an
+	// image. Defaults to 4.
+	Spread float64
+	// The winding convention of the polygons given to SignedDistanceField, and of
+	// the polygons traced from the field by TraceSDF
+	Winding WindingConvention
+}
+
+func (options SDFOptions) withDefaults() SDFOptions {
+	if options.Scale <= 0 {
+		options.Scale = 1
+	}
+	if options.Spread <= 0 {
+		options.Spread = 4
+	}
+	return options
+}
+
+// Compute the exact signed distance field of polygons over the given bounds.
+// Coordinates are mapped just like RasterizeAlpha, so the field of a trace
+// result over the bounds of the traced image, multiplied by the scale, lines
+// up with the image.
+func SignedDistanceField(polygons []Polygon, r image.Rectangle, options SDFOptions) *SDF {
+	options = options.withDefaults()
+	polygons = options.Winding.Convert(polygons)
+	sdf := &SDF{
+		Rect:      r,
+		Distances: make([]float64, r.Dx()*r.Dy()),
+		Scale:     options.Scale,
+		Spread:    options.Spread,
+		Winding:   options.Winding,
+	}
+
+	var segments [][2]Point
+	for _, polygon := range polygons {
+		for i := range polygon {
+			segments = append(segments, [2]Point{polygon[i], polygon[(i+1)%len(polygon)]})
+		}
+	}
+	grid := newSegmentGrid(segments)
+	limit := options.Spread / options.Scale
+
+	inside := make([]bool, r.Dx())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		traceY := sdf.traceCoordinate(y)
+		for i := range inside {
+			inside[i] = false
+		}
+		fillSpans(polygons, traceY, func(start, end float64) {
+			// Field pixels whose trace coordinates are within the span
+			first := maxInt(r.Min.X, int(math.Ceil((start+0.5)*options.Scale-0.5)))
+			last := minInt(r.Max.X-1, int(math.Floor((end+0.5)*options.Scale-0.5)))
+			for x := first; x <= last; x++ {
+				inside[x-r.Min.X] = true
+			}
+		})
+
+		for x := r.Min.X; x < r.Max.X; x++ {
+			p := Point{sdf.traceCoordinate(x), traceY}
+			distance := math.Min(grid.distanceTo(p, limit)*options.Scale, options.Spread)
+			if inside[x-r.Min.X] {
+				distance = -distance
+			}
+			sdf.Distances[sdf.offset(x, y)] = distance
+		}
+	}
+	return sdf
+}
+
+// Decode a signed distance field from an image encoded like SDF.Image. The
+// image's gray level is used, so fields stored in the alpha channel of an
+// otherwise transparent image also work.
+func SDFFromImage(img image.Image, options SDFOptions) *SDF {
+	options = options.withDefaults()
+	bounds := img.Bounds()
+	sdf := &SDF{
+		Rect:      bounds,
+		Distances: make([]float64, bounds.Dx()*bounds.Dy()),
+		Scale:     options.Scale,
+		Spread:    options.Spread,
+		Winding:   options.Winding,
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			level := float64(color.Gray16Model.Convert(img.At(x, y)).(color.Gray16).Y) / 0xffff
+			sdf.Distances[sdf.offset(x, y)] = (0.5 - level) * 2 * options.Spread
+		}
+	}
+	return sdf
+}
+
+func (sdf *SDF) offset(x, y int) int {
+	return (y-sdf.Rect.Min.Y)*sdf.Rect.Dx() + (x - sdf.Rect.Min.X)
+}
+
+// The trace coordinate of the center of a field pixel, along either axis
+func (sdf *SDF) traceCoordinate(i int) float64 {
+	return (float64(i)+0.5)/sdf.Scale - 0.5
+}
+
+// The distance at a field pixel. Pixels outside the field are treated as being
+// as far outside as the field goes.
+func (sdf *SDF) At(x, y int) float64 {
+	if !(image.Point{x, y}).In(sdf.Rect) {
+		return sdf.Spread
+	}
+	return sdf.Distances[sdf.offset(x, y)]
+}
+
+// Encode the field as an 8-bit grayscale image, in the usual way for SDF
+// textures. The outline is at middle gray, and the levels run to white at the
+// spread inside, and black at the spread outside.
+func (sdf *SDF) Image() *image.Gray {
+	img := image.NewGray(sdf.Rect)
+	for i, distance := range sdf.Distances {
+		level := 0.5 - distance/(2*sdf.Spread)
+		img.Pix[img.PixOffset(sdf.Rect.Min.X+i%sdf.Rect.Dx(), sdf.Rect.Min.Y+i/sdf.Rect.Dx())] =
+			uint8(math.Round(math.Max(0, math.Min(1, level)) * 0xff))
+	}
+	return img
+}
+
+// Trace the outline of a signed distance field, where the distance crosses
+// zero. Crossings are interpolated between samples, so the outline is placed
+// to a fraction of a pixel, unlike tracing a bitmap. The polygons are in trace
+// coordinates, undoing the field's scale, and are wound in the field's winding
+// convention. Fields decoded from 8-bit images are quantized, so they won't
+// come out quite as precisely as fields computed directly.
+//
+// Polygons have a vertex in every field pixel they pass through, which is
+// usually far more than needed. SimplifyToVertexCount or ResamplePolygon can
+// thin them out.
+func TraceSDF(sdf *SDF) []Polygon {
+	// A crossing is identified by the grid edge it is on. Horizontal edges join
+	// a sample to the one to its right, and vertical edges to the one below.
+	type gridEdge struct {
+		x, y       int
+		horizontal bool
+	}
+	type sdfSegment struct {
+		start, end Point
+		next       gridEdge
+	}
+	segments := make(map[gridEdge]sdfSegment)
+
+	crossing := func(x0, y0, x1, y1 int) Point {
+		d0, d1 := sdf.At(x0, y0), sdf.At(x1, y1)
+		// Keep crossings off the samples themselves, so that outlines never meet
+		// at a sample that is exactly zero
+		t := math.Max(1e-6, math.Min(1-1e-6, d0/(d0-d1)))
+		return Point{
+			sdf.traceCoordinate(x0) + (sdf.traceCoordinate(x1)-sdf.traceCoordinate(x0))*t,
+			sdf.traceCoordinate(y0) + (sdf.traceCoordinate(y1)-sdf.traceCoordinate(y0))*t,
+		}
+	}
+
+	// Work through every cell between four samples, including those hanging
+	// off the edge of the field, so that every outline is closed
+	for y := sdf.Rect.Min.Y - 1; y < sdf.Rect.Max.Y; y++ {
+		for x := sdf.Rect.Min.X - 1; x < sdf.Rect.Max.X; x++ {
+			// Corners clockwise from the top left, and the edges that follow each
+			// of them
+			corners := [4]image.Point{{x, y}, {x + 1, y}, {x + 1, y + 1}, {x, y + 1}}
+			edges := [4]gridEdge{{x, y, true}, {x + 1, y, false}, {x, y + 1, true}, {x, y, false}}
+			var inside [4]bool
+			insideCount := 0
+			for i, corner := range corners {
+				inside[i] = sdf.At(corner.X, corner.Y) < 0
+				if inside[i] {
+					insideCount++
+				}
+			}
+			if insideCount == 0 || insideCount == 4 {
+				continue
+			}
+
+			edgePoint := func(i int) Point {
+				a, b := corners[i], corners[(i+1)%4]
+				return crossing(a.X, a.Y, b.X, b.Y)
+			}
+			// Add a segment across the cell between the edges on either side of
+			// a corner, with the inside on the left
+			cutCorner := func(corner int) {
+				before, after := (corner+3)%4, corner
+				start, end := edges[before], edges[after]
+				a, b := edgePoint(before), edgePoint(after)
+				p := Point{sdf.traceCoordinate(corners[corner].X), sdf.traceCoordinate(corners[corner].Y)}
+				if (cross(a, b, p) > 0) != inside[corner] {
+					start, end = end, start
+					a, b = b, a
+				}
+				segments[start] = sdfSegment{a, b, end}
+			}
+
+			switch {
+			case insideCount == 1 || insideCount == 3:
+				// Cut off the corner that is different from the others
+				for i := range corners {
+					if inside[i] == (insideCount == 1) {
+						cutCorner(i)
+					}
+				}
+			case inside[0] == inside[1] || inside[1] == inside[2]:
+				// Two neighboring corners inside, so the outline crosses straight
+				// over. Find the edges that cross and join them.
+				var crossed []int
+				for i := range corners {
+					if inside[i] != inside[(i+1)%4] {
+						crossed = append(crossed, i)
+					}
+				}
+				start, end := edges[crossed[0]], edges[crossed[1]]
+				a, b := edgePoint(crossed[0]), edgePoint(crossed[1])
+				// The inside corner after the first crossed edge must be on the left
+				p := corners[(crossed[0]+1)%4]
+				insidePoint := Point{sdf.traceCoordinate(p.X), sdf.traceCoordinate(p.Y)}
+				if (cross(a, b, insidePoint) > 0) != inside[(crossed[0]+1)%4] {
+					start, end = end, start
+					a, b = b, a
+				}
+				segments[start] = sdfSegment{a, b, end}
+			default:
+				// A saddle. Average the corners to decide whether the center is
+				// inside, and cut off the two corners that differ from it.
+				center := 0.0
+				for _, corner := range corners {
+					center += sdf.At(corner.X, corner.Y)
+				}
+				centerInside := center < 0
+				for i := range corners {
+					if inside[i] != centerInside {
+						cutCorner(i)
+					}
+				}
+			}
+		}
+	}
+
+	// Join the segments up into loops
+	var polygons []Polygon
+	for len(segments) > 0 {
+		var key gridEdge
+		for k := range segments {
+			key = k
+			break
+		}
+		var polygon Polygon
+		for {
+			segment, ok := segments[key]
+			if !ok {
+				break
+			}
+			delete(segments, key)
+			polygon = append(polygon, segment.start)
+			key = segment.next
+		}
+		if len(polygon) >= 3 {
+			polygons = append(polygons, polygon)
+		}
+	}
+	return sdf.Winding.Convert(polygons)
+}
@@ -0,0 +1,82 @@
+package simpletrace
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignedDistanceField(t *testing.T) {
+	square := squarePolygon(2, 2, 10)
+	sdf := SignedDistanceField([]Polygon{square}, image.Rect(0, 0, 18, 18), SDFOptions{Spread: 3})
+	assert.InDelta(t, -3, sdf.At(7, 7), 1e-9)
+	assert.InDelta(t, -1, sdf.At(3, 7), 1e-9)
+	assert.InDelta(t, 1, sdf.At(1, 7), 1e-9)
+	assert.InDelta(t, 3, sdf.At(7, 16), 1e-9)
+	assert.InDelta(t, math.Sqrt2, sdf.At(1, 1), 1e-9)
+
+	// At twice the resolution, distances are in the finer pixels
+	sdf = SignedDistanceField([]Polygon{square}, image.Rect(0, 0, 30, 30), SDFOptions{Scale: 2, Spread: 4})
+	// Field pixel 5 is centered on trace coordinate 2.25
+	assert.InDelta(t, -0.5, sdf.At(5, 15), 1e-9)
+	assert.InDelta(t, 0.5, sdf.At(4, 15), 1e-9)
+
+	img := sdf.Image()
+	assert.Equal(t, uint8(0xff), img.GrayAt(15, 15).Y)
+	assert.Equal(t, uint8(0), img.GrayAt(0, 0).Y)
+	// Half a pixel inside is an eighth of the way from middle gray to white
+	assert.Equal(t, uint8(143), img.GrayAt(5, 15).Y)
+}
+
+func TestTraceSDF(t *testing.T) {
+	// A ring, which traces with a hole
+	var outer, inner Polygon
+	for i := 0; i < 64; i++ {
+		angle := 2 * math.Pi * float64(i) / 64
+		outer = append(outer, Point{20 + 15*math.Cos(angle), 20 + 15*math.Sin(angle)})
+		inner = append(inner, Point{20 + 6*math.Cos(-angle), 20 + 6*math.Sin(-angle)})
+	}
+	assert.False(t, outer.IsHole())
+	assert.True(t, inner.IsHole())
+	ring := []Polygon{outer, inner}
+
+	sdf := SignedDistanceField(ring, image.Rect(0, 0, 80, 80), SDFOptions{Scale: 2})
+	traced := TraceSDF(sdf)
+	assert.Empty(t, Validate(traced))
+	filled, holes := countWindings(traced)
+	assert.Equal(t, 1, filled)
+	assert.Equal(t, 1, holes)
+	assert.InEpsilon(t, totalSignedArea(ring), totalSignedArea(traced), 0.01)
+
+	// Every vertex is very close to the original outline
+	var segments [][2]Point
+	for _, polygon := range ring {
+		for i := range polygon {
+			segments = append(segments, [2]Point{polygon[i], polygon[(i+1)%len(polygon)]})
+		}
+	}
+	grid := newSegmentGrid(segments)
+	for _, polygon := range traced {
+		for _, p := range polygon {
+			assert.Less(t, grid.distanceTo(p, math.Inf(1)), 0.05)
+		}
+	}
+
+	// Round trip through an 8-bit image
+	decoded := SDFFromImage(sdf.Image(), SDFOptions{Scale: 2})
+	traced = TraceSDF(decoded)
+	assert.Empty(t, Validate(traced))
+	assert.InEpsilon(t, totalSignedArea(ring), totalSignedArea(traced), 0.02)
+}
+
+func TestTraceSDFFromBitmap(t *testing.T) {
+	bitmap := discBitmap(30, 10)
+	polygons := TraceImage(bitmap, OpacityColorFilledFunc)
+	sdf := SignedDistanceField(polygons, image.Rect(0, 0, 120, 120), SDFOptions{Scale: 4})
+	traced := TraceSDF(sdf)
+	assert.Empty(t, Validate(traced))
+	assert.Len(t, traced, 1)
+	assert.InEpsilon(t, totalSignedArea(polygons), totalSignedArea(traced), 0.01)
+}
@@ -4,10 +4,51 @@ import (
 	"image"
 )
 
-func TraceImage(img image.Image, isColorFilledFunc IsColorFilledFunc) [][]Point {
+// Options for TraceImageWithOptions. The zero value traces exactly like
+// TraceImage.
+type TraceOptions struct {
+	// Filled polygons with a smaller area than this, in square pixels, are
+	// dropped along with anything inside them. This is similar to potrace's
+	// turdsize, and is useful for removing dust and noise from scans.
+	MinFilledArea float64
+	// Holes with a smaller area than this, in square pixels, are filled in,
+	// along with any islands inside them.
+	MinHoleArea float64
+	// Like MinFilledArea and MinHoleArea, but counting the pixels of the bitmap
+	// in each region instead of measuring the traced polygon. Pixels inside
+	// islands or holes nested in a region don't count toward it. A polygon is
+	// dropped if it falls below either threshold.
+	MinFilledPixels int
+	MinHolePixels   int
+	// Operations applied to the bitmap after the image is classified, but before
+	// it is traced, such as DilateFilter or FillHolesFilter. They are applied in
+	// order.
+	Preprocess []BitmapFilter
+	// What to do with holes, for targets that can't represent them. By default
+	// they are kept as separate polygons.
+	Holes HoleMode
+	// How the result is mapped from pixel coordinates into output coordinates,
+	// such as flipping y to point up or converting to physical units
+	Output OutputTransform
+	// Which way filled polygons and holes are wound in the result. By default,
+	// filled polygons are counterclockwise and holes clockwise.
+	Winding WindingConvention
+}
+
+func TraceImage(img image.Image, isColorFilledFunc IsColorFilledFunc) []Polygon {
+	return TraceImageWithOptions(img, isColorFilledFunc, TraceOptions{})
+}
+
+func TraceImageWithOptions(img image.Image, isColorFilledFunc IsColorFilledFunc, options TraceOptions) []Polygon {
 	// Make the square map
-	squaremap := getSquaresForImage(img, isColorFilledFunc)
+	squaremap := getSquaresForImage(img, isColorFilledFunc, options.Preprocess)
 	// Get the polygons
 	polygons := squaremap.convertSquaresToPolygons()
+	// Remove speckles
+	polygons = filterSpeckles(polygons, options)
+	polygons = applyHoleMode(polygons, options.Holes)
+	// Move into output coordinates
+	polygons = options.Output.apply(polygons, img.Bounds())
+	polygons = options.Winding.Convert(polygons)
 	return polygons
 }
@@ -0,0 +1,161 @@
+package simpletrace
+
+import (
+	"container/heap"
+	"math"
+)
+
+// Reduce a trace result to at most maxVertices vertices in total, by repeatedly
+// removing whichever vertex changes the area of its polygon the least
+// (Visvalingam-Whyatt). Every polygon keeps at least three vertices, and
+// vertices are never removed if that would make an edge cross another edge, so
+// the budget may not be reached for very small budgets.
+func SimplifyToVertexCount(polygons []Polygon, maxVertices int) []Polygon {
+	return simplifyToVertexCount(polygons, maxVertices, nil)
+}
+
+// A vertex in a polygon being simplified
+type simplifyVertex struct {
+	point      Point
+	polygon    int
+	prev, next *simplifyVertex
+	cost       float64
+	removed    bool
+	heapIndex  int
+}
+
+type simplifyHeap []*simplifyVertex
+
+func (h simplifyHeap) Len() int           { return len(h) }
+func (h simplifyHeap) Less(i, j int) bool { return h[i].cost < h[j].cost }
+func (h simplifyHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIndex = i
+	h[j].heapIndex = j
+}
+func (h *simplifyHeap) Push(x interface{}) {
+	v := x.(*simplifyVertex)
+	v.heapIndex = len(*h)
+	*h = append(*h, v)
+}
+func (h *simplifyHeap) Pop() interface{} {
+	old := *h
+	v := old[len(old)-1]
+	v.heapIndex = -1
+	*h = old[:len(old)-1]
+	return v
+}
+
+// Simplify as in SimplifyToVertexCount. If canRemove is given, a vertex is only
+// removed if canRemove returns true for it and its neighbors. shrinks is true
+// when removing the vertex would cut the triangle prev, v, next away from the
+// filled side of the polygon, rather than adding it.
+func simplifyToVertexCount(polygons []Polygon, maxVertices int, canRemove func(prev, v, next Point, shrinks bool) bool) []Polygon {
+	total := 0
+	for _, polygon := range polygons {
+		total += len(polygon)
+	}
+	if maxVertices <= 0 || total <= maxVertices {
+		return polygons
+	}
+
+	sizes := make([]int, len(polygons))
+	var all []*simplifyVertex
+	var vertexHeap simplifyHeap
+	for i, polygon := range polygons {
+		sizes[i] = len(polygon)
+		first := len(all)
+		for _, p := range polygon {
+			all = append(all, &simplifyVertex{point: p, polygon: i})
+		}
+		ring := all[first:]
+		for j, v := range ring {
+			v.prev = ring[(j+len(ring)-1)%len(ring)]
+			v.next = ring[(j+1)%len(ring)]
+		}
+	}
+
+	updateCost := func(v *simplifyVertex) {
+		area := cross(v.prev.point, v.point, v.next.point) / 2
+		v.cost = math.Abs(area)
+		if canRemove != nil && !canRemove(v.prev.point, v.point, v.next.point, area > 0) {
+			v.cost = math.Inf(1)
+		}
+	}
+	for _, v := range all {
+		updateCost(v)
+		heap.Push(&vertexHeap, v)
+	}
+
+	// Whether the edge from a to b would cross any edge other than those
+	// touching a or b
+	crossesAnyEdge := func(a, b *simplifyVertex) bool {
+		for _, v := range all {
+			if v.removed || v == a || v == b || v.next == a || v.next == b {
+				continue
+			}
+			if segmentsIntersect(a.point, b.point, v.point, v.next.point) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for total > maxVertices && vertexHeap.Len() > 0 {
+		v := heap.Pop(&vertexHeap).(*simplifyVertex)
+		if math.IsInf(v.cost, 1) {
+			break
+		}
+		if sizes[v.polygon] <= 3 {
+			continue
+		}
+		if crossesAnyEdge(v.prev, v.next) {
+			// This vertex gets another chance if one of its neighbors changes
+			continue
+		}
+
+		v.removed = true
+		v.prev.next = v.next
+		v.next.prev = v.prev
+		sizes[v.polygon]--
+		total--
+
+		for _, neighbor := range []*simplifyVertex{v.prev, v.next} {
+			updateCost(neighbor)
+			if neighbor.heapIndex < 0 {
+				heap.Push(&vertexHeap, neighbor)
+			} else {
+				heap.Fix(&vertexHeap, neighbor.heapIndex)
+			}
+		}
+	}
+
+	result := make([]Polygon, len(polygons))
+	for _, v := range all {
+		if !v.removed {
+			result[v.polygon] = append(result[v.polygon], v.point)
+		}
+	}
+	return result
+}
+
+// Whether the segments ab and cd intersect or touch
+func segmentsIntersect(a, b, c, d Point) bool {
+	d1 := cross(c, d, a)
+	d2 := cross(c, d, b)
+	d3 := cross(a, b, c)
+	d4 := cross(a, b, d)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && onSegment(c, d, a)) ||
+		(d2 == 0 && onSegment(c, d, b)) ||
+		(d3 == 0 && onSegment(a, b, c)) ||
+		(d4 == 0 && onSegment(a, b, d))
+}
+
+// Whether p, which is collinear with ab, lies between a and b
+func onSegment(a, b, p Point) bool {
+	return math.Min(a.X, b.X) <= p.X && p.X <= math.Max(a.X, b.X) &&
+		math.Min(a.Y, b.Y) <= p.Y && p.Y <= math.Max(a.Y, b.Y)
+}
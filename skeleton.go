@@ -0,0 +1,214 @@
+package simpletrace
+
+import (
+	"image"
+	"math"
+)
+
+// The eight neighbors of a pixel, clockwise from the one above
+var neighborOffsets = [8]image.Point{{0, -1}, {1, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}}
+
+// Thin the filled regions of the bitmap down to lines one pixel wide, running
+// down their middles, without changing how they connect. Each 8-connected
+// region becomes a single 8-connected skeleton, and holes are kept.
+//
+// This uses the Zhang-Suen thinning algorithm, followed by a pass which removes
+// the extra pixels it leaves at the corners of diagonal staircases.
+func (b *Bitmap) Skeletonize() *Bitmap {
+	result := b.Clone()
+	neighbors := func(x, y int) (filled [8]bool, count int) {
+		for i, offset := range neighborOffsets {
+			filled[i] = result.Filled(x+offset.X, y+offset.Y)
+			if filled[i] {
+				count++
+			}
+		}
+		return
+	}
+
+	var marked []image.Point
+	isMarked := make(map[image.Point]bool)
+	for changed := true; changed; {
+		changed = false
+		for pass := 0; pass < 2; pass++ {
+			marked = marked[:0]
+			for key := range isMarked {
+				delete(isMarked, key)
+			}
+			for y := result.Rect.Min.Y; y < result.Rect.Max.Y; y++ {
+				for x := result.Rect.Min.X; x < result.Rect.Max.X; x++ {
+					if !result.Filled(x, y) {
+						continue
+					}
+					n, count := neighbors(x, y)
+					if count < 2 || count > 6 || filledRuns(n) != 1 {
+						continue
+					}
+					// The first pass peels from the bottom right, and the second from
+					// the top left
+					up, right, down, left := n[0], n[2], n[4], n[6]
+					if pass == 0 && (up && right && down || right && down && left) {
+						continue
+					}
+					if pass == 1 && (up && right && left || up && down && left) {
+						continue
+					}
+					marked = append(marked, image.Point{x, y})
+					isMarked[image.Point{x, y}] = true
+				}
+			}
+			for _, p := range marked {
+				// Zhang-Suen erases small blocks entirely, so leave any pixel whose
+				// neighbors are all going too
+				survivor := false
+				for _, offset := range neighborOffsets {
+					q := p.Add(offset)
+					if result.Filled(q.X, q.Y) && !isMarked[q] {
+						survivor = true
+						break
+					}
+				}
+				if survivor {
+					result.Pix[result.offset(p.X, p.Y)] = false
+					changed = true
+				}
+			}
+		}
+	}
+
+	// Remove pixels at the inside corners of staircases, whose neighbors on
+	// either side of the corner already touch diagonally
+	for y := result.Rect.Min.Y; y < result.Rect.Max.Y; y++ {
+		for x := result.Rect.Min.X; x < result.Rect.Max.X; x++ {
+			if !result.Filled(x, y) {
+				continue
+			}
+			n, count := neighbors(x, y)
+			up, right, down, left := n[0], n[2], n[4], n[6]
+			corner := up && right || right && down || down && left || left && up
+			if count >= 2 && corner && neighborComponents(n) == 1 {
+				result.Pix[result.offset(x, y)] = false
+			}
+		}
+	}
+	return result
+}
+
+// The number of runs of filled pixels going around the neighbors of a pixel
+func filledRuns(n [8]bool) int {
+	runs := 0
+	for i := range n {
+		if !n[i] && n[(i+1)%8] {
+			runs++
+		}
+	}
+	return runs
+}
+
+// The number of separate groups of filled neighbors, counting neighbors as
+// connected when they touch, including diagonally
+func neighborComponents(n [8]bool) int {
+	components := filledRuns(n)
+	if components == 0 {
+		return 0
+	}
+	// Runs separated only by an empty diagonal neighbor still touch, because
+	// the edge neighbors on either side of it are adjacent
+	for i := 1; i < 8; i += 2 {
+		if !n[i] && n[i-1] && n[(i+1)%8] {
+			components--
+		}
+	}
+	if components < 1 {
+		components = 1
+	}
+	return components
+}
+
+// The Euclidean distance from the center of each pixel to the center of the
+// nearest empty pixel, indexed like Pix. Empty pixels are at distance zero, and
+// pixels outside the bitmap count as empty.
+//
+// This is the exact transform of Felzenszwalb and Huttenlocher, which runs in
+// linear time.
+func (b *Bitmap) DistanceTransform() []float64 {
+	width, height := b.Rect.Dx(), b.Rect.Dy()
+	// Work on a grid with a border of empty pixels around it
+	paddedWidth, paddedHeight := width+2, height+2
+	squared := make([]float64, paddedWidth*paddedHeight)
+	for y := 0; y < paddedHeight; y++ {
+		for x := 0; x < paddedWidth; x++ {
+			if b.Filled(b.Rect.Min.X+x-1, b.Rect.Min.Y+y-1) {
+				squared[y*paddedWidth+x] = math.Inf(1)
+			}
+		}
+	}
+
+	column := make([]float64, paddedHeight)
+	for x := 0; x < paddedWidth; x++ {
+		for y := range column {
+			column[y] = squared[y*paddedWidth+x]
+		}
+		column = distanceTransform1D(column)
+		for y, value := range column {
+			squared[y*paddedWidth+x] = value
+		}
+	}
+	for y := 0; y < paddedHeight; y++ {
+		row := squared[y*paddedWidth : (y+1)*paddedWidth]
+		copy(row, distanceTransform1D(row))
+	}
+
+	distances := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			distances[y*width+x] = math.Sqrt(squared[(y+1)*paddedWidth+x+1])
+		}
+	}
+	return distances
+}
+
+// The squared distance transform of a sampled function in one dimension, as the
+// lower envelope of parabolas rooted at each sample
+func distanceTransform1D(f []float64) []float64 {
+	n := len(f)
+	result := make([]float64, n)
+	roots := make([]int, 0, n)          // Samples whose parabolas form the envelope
+	boundaries := make([]float64, 0, n) // Where each parabola takes over from the last
+	for q := 0; q < n; q++ {
+		if math.IsInf(f[q], 1) {
+			continue
+		}
+		for len(roots) > 0 {
+			r := roots[len(roots)-1]
+			s := ((f[q] + float64(q*q)) - (f[r] + float64(r*r))) / float64(2*(q-r))
+			if s > boundaries[len(boundaries)-1] {
+				roots = append(roots, q)
+				boundaries = append(boundaries, s)
+				break
+			}
+			roots = roots[:len(roots)-1]
+			boundaries = boundaries[:len(boundaries)-1]
+		}
+		if len(roots) == 0 {
+			roots = append(roots, q)
+			boundaries = append(boundaries, math.Inf(-1))
+		}
+	}
+	if len(roots) == 0 {
+		for i := range result {
+			result[i] = math.Inf(1)
+		}
+		return result
+	}
+
+	k := 0
+	for q := 0; q < n; q++ {
+		for k+1 < len(roots) && boundaries[k+1] < float64(q) {
+			k++
+		}
+		d := float64(q - roots[k])
+		result[q] = d*d + f[roots[k]]
+	}
+	return result
+}
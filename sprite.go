@@ -0,0 +1,92 @@
+package simpletrace
+
+import (
+	"image"
+	"math"
+)
+
+type SpriteMeshOptions struct {
+	// Decides which pixels must be covered by the mesh. Defaults to
+	// OpacityColorFilledFunc.
+	IsColorFilled IsColorFilledFunc
+	// Grow the covered region by this many pixels before tracing. A pixel or two
+	// of padding keeps soft edges and texture filtering from being clipped, and
+	// gives the simplifier room to work.
+	Dilate int
+	// Simplify the outline to at most this many vertices. Vertices are never
+	// removed if that would uncover a filled pixel, so the budget is not always
+	// met. Zero means no limit.
+	MaxVertices int
+	Mode        TriangulationMode
+	// Which way the triangles are wound, using the same convention as trace
+	// results
+	Winding WindingConvention
+}
+
+// A triangle mesh covering the visible part of a sprite, laid out for upload to
+// a GPU.
+type SpriteMesh struct {
+	// Vertex positions as x, y pairs, in pixels from the top left corner of the
+	// image bounds
+	Positions []float32
+	// Texture coordinates as u, v pairs, from 0 to 1 across the image bounds,
+	// with v increasing downward like the image
+	UVs []float32
+	// Triangle vertex indices, three per triangle
+	Indices []uint32
+}
+
+// Build a tight mesh around the filled pixels of a sprite, which can be drawn
+// instead of a full quad to cut down on overdraw.
+func BuildSpriteMesh(img image.Image, options SpriteMeshOptions) SpriteMesh {
+	isColorFilled := options.IsColorFilled
+	if isColorFilled == nil {
+		isColorFilled = OpacityColorFilledFunc
+	}
+
+	bitmap := BitmapFromImage(img, isColorFilled)
+	traced := bitmap
+	if options.Dilate > 0 {
+		traced = bitmap.Dilate(DiscStructuringElement(options.Dilate))
+	}
+	polygons := getSquaresForBitmap(traced).convertSquaresToPolygons()
+
+	// Only allow the simplifier to cut away triangles that contain no filled
+	// pixels. Pixel centers sit at integer coordinates in trace space.
+	polygons = simplifyToVertexCount(polygons, options.MaxVertices, func(prev, v, next Point, shrinks bool) bool {
+		if !shrinks {
+			return true
+		}
+		minX := int(math.Ceil(math.Min(prev.X, math.Min(v.X, next.X))))
+		maxX := int(math.Floor(math.Max(prev.X, math.Max(v.X, next.X))))
+		minY := int(math.Ceil(math.Min(prev.Y, math.Min(v.Y, next.Y))))
+		maxY := int(math.Floor(math.Max(prev.Y, math.Max(v.Y, next.Y))))
+		for y := minY; y <= maxY; y++ {
+			for x := minX; x <= maxX; x++ {
+				if bitmap.Filled(x, y) && pointInTriangle(PointFromInts(x, y), prev, v, next) {
+					return false
+				}
+			}
+		}
+		return true
+	})
+
+	mesh := Triangulate(polygons, options.Mode)
+	mesh.wind(options.Winding)
+
+	bounds := img.Bounds()
+	width, height := float64(bounds.Dx()), float64(bounds.Dy())
+	var sprite SpriteMesh
+	for _, p := range mesh.Vertices {
+		// Trace space puts pixel centers on integers, so shift by half a pixel to
+		// get to pixel edges, and keep everything on the image.
+		x := math.Max(0, math.Min(width, p.X+0.5-float64(bounds.Min.X)))
+		y := math.Max(0, math.Min(height, p.Y+0.5-float64(bounds.Min.Y)))
+		sprite.Positions = append(sprite.Positions, float32(x), float32(y))
+		sprite.UVs = append(sprite.UVs, float32(x/width), float32(y/height))
+	}
+	for _, triangle := range mesh.Triangles {
+		sprite.Indices = append(sprite.Indices, uint32(triangle[0]), uint32(triangle[1]), uint32(triangle[2]))
+	}
+	return sprite
+}
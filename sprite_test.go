@@ -0,0 +1,53 @@
+package simpletrace
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSpriteMesh(t *testing.T) {
+	// An opaque disc with a transparent hole, touching the edge of the image
+	img := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			dx, dy := float64(x)-16, float64(y)-16
+			distance := dx*dx + dy*dy
+			if distance < 16*16 && distance > 4*4 {
+				img.Set(x, y, color.NRGBA{0x80, 0x40, 0x20, 0xff})
+			}
+		}
+	}
+
+	sprite := BuildSpriteMesh(img, SpriteMeshOptions{Dilate: 1, MaxVertices: 40, Mode: TriangulateDelaunay})
+	assert.LessOrEqual(t, len(sprite.Positions)/2, 40)
+	assert.Equal(t, len(sprite.Positions), len(sprite.UVs))
+	assert.Equal(t, 0, len(sprite.Indices)%3)
+	for _, uv := range sprite.UVs {
+		assert.GreaterOrEqual(t, uv, float32(0))
+		assert.LessOrEqual(t, uv, float32(1))
+	}
+
+	vertex := func(i uint32) Point {
+		return Point{float64(sprite.Positions[2*i]), float64(sprite.Positions[2*i+1])}
+	}
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			_, _, _, alpha := img.At(x, y).RGBA()
+			if alpha == 0 {
+				continue
+			}
+			center := Point{float64(x) + 0.5, float64(y) + 0.5}
+			covered := false
+			for i := 0; i < len(sprite.Indices); i += 3 {
+				if pointInTriangle(center, vertex(sprite.Indices[i]), vertex(sprite.Indices[i+1]), vertex(sprite.Indices[i+2])) {
+					covered = true
+					break
+				}
+			}
+			assert.True(t, covered, "pixel (%d, %d) is not covered", x, y)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package simpletrace
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Convert to bitmap by a lightness threshold, where pixels darker than the
+// threshold are filled
+func DarkThresholdColorFilledFunc(threshold uint8) IsColorFilledFunc {
+	return func(c color.Color) bool {
+		if !OpacityColorFilledFunc(c) {
+			return false
+		}
+		return yValueFromColor(c) < threshold
+	}
+}
+
+// Convert to bitmap by a lightness threshold, where pixels lighter than the
+// threshold are filled
+func LightThresholdColorFilledFunc(threshold uint8) IsColorFilledFunc {
+	return func(c color.Color) bool {
+		if !OpacityColorFilledFunc(c) {
+			return false
+		}
+		return yValueFromColor(c) > threshold
+	}
+}
+
+// Find the lightness threshold which best separates the image into light and
+// dark pixels, using Otsu's method. Transparent pixels are ignored.
+func OtsuThreshold(img image.Image) uint8 {
+	var histogram [256]int
+	total := 0
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := img.At(x, y)
+			if !OpacityColorFilledFunc(c) {
+				continue
+			}
+			histogram[yValueFromColor(c)]++
+			total++
+		}
+	}
+	if total == 0 {
+		return 0x80
+	}
+
+	sum := 0.0
+	for value, count := range histogram {
+		sum += float64(value * count)
+	}
+
+	// Pick the threshold which maximizes the variance between the two classes.
+	// Pixels at or below the threshold are in the dark class.
+	var best uint8
+	bestVariance := -1.0
+	darkCount := 0
+	darkSum := 0.0
+	for value := 0; value < 256; value++ {
+		darkCount += histogram[value]
+		darkSum += float64(value * histogram[value])
+		lightCount := total - darkCount
+		if darkCount == 0 || lightCount == 0 {
+			continue
+		}
+		darkMean := darkSum / float64(darkCount)
+		lightMean := (sum - darkSum) / float64(lightCount)
+		variance := float64(darkCount) * float64(lightCount) * (darkMean - lightMean) * (darkMean - lightMean)
+		if variance > bestVariance {
+			bestVariance = variance
+			best = uint8(value)
+		}
+	}
+	return best
+}
+
+// Like DarkColorFilledFunc, but with the threshold picked for the image by
+// Otsu's method
+func OtsuDarkColorFilledFunc(img image.Image) IsColorFilledFunc {
+	// Otsu's threshold is the last dark value, so fill anything below the next
+	// value up.
+	threshold := int(OtsuThreshold(img)) + 1
+	if threshold > 0xff {
+		threshold = 0xff
+	}
+	return DarkThresholdColorFilledFunc(uint8(threshold))
+}
+
+// Like LightColorFilledFunc, but with the threshold picked for the image by
+// Otsu's method
+func OtsuLightColorFilledFunc(img image.Image) IsColorFilledFunc {
+	return LightThresholdColorFilledFunc(OtsuThreshold(img))
+}
+
+// How the local threshold is computed by AdaptiveThreshold
+type AdaptiveMethod uint8
+
+const (
+	// The threshold is the mean lightness of the window, minus Offset
+	AdaptiveMean = AdaptiveMethod(iota)
+	// The threshold is a Gaussian weighted mean of the window, minus Offset. The
+	// standard deviation of the Gaussian is half the radius.
+	AdaptiveGaussian
+	// Sauvola's method, which adjusts the mean by the local contrast. This works
+	// well for text on unevenly lit or stained paper.
+	AdaptiveSauvola
+)
+
+type AdaptiveThresholdOptions struct {
+	Method AdaptiveMethod
+	// Size of the window around each pixel, as the distance from the center to
+	// the edge. Defaults to 7.
+	Radius int
+	// Subtracted from the mean for AdaptiveMean and AdaptiveGaussian. Positive
+	// values make filling dark pixels stricter.
+	Offset float64
+	// Sensitivity to contrast for AdaptiveSauvola, usually between 0.2 and 0.5.
+	// Defaults to 0.34.
+	K float64
+	// Dynamic range of the standard deviation for AdaptiveSauvola. Defaults to
+	// 128.
+	R float64
+	// Fill light pixels instead of dark pixels
+	Light bool
+}
+
+// Classify an image by comparing each pixel to a threshold computed from its
+// neighborhood, which copes with uneven lighting that defeats a global
+// threshold. Transparent pixels are never filled, and are left out of the
+// neighborhoods of other pixels, so they don't skew thresholds near the edges
+// of opaque areas.
+//
+// The threshold depends on where a pixel is, not just its color, so the result
+// is a Bitmap rather than an IsColorFilledFunc. Bitmaps are images, so the
+// result can be traced with OpacityColorFilledFunc.
+func AdaptiveThreshold(img image.Image, options AdaptiveThresholdOptions) *Bitmap {
+	if options.Radius <= 0 {
+		options.Radius = 7
+	}
+	if options.K == 0 {
+		options.K = 0.34
+	}
+	if options.R == 0 {
+		options.R = 128
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	lightness := make([]float64, width*height)
+	// 1 for opaque pixels and 0 for transparent ones, so that the statistics
+	// only count opaque pixels
+	weights := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := img.At(bounds.Min.X+x, bounds.Min.Y+y)
+			if OpacityColorFilledFunc(c) {
+				lightness[y*width+x] = float64(yValueFromColor(c))
+				weights[y*width+x] = 1
+			}
+		}
+	}
+
+	var thresholds []float64
+	switch options.Method {
+	case AdaptiveGaussian:
+		sigma := float64(options.Radius) / 2
+		thresholds = gaussianBlur(lightness, width, height, sigma)
+		blurredWeights := gaussianBlur(weights, width, height, sigma)
+		for i := range thresholds {
+			if blurredWeights[i] > 0 {
+				thresholds[i] /= blurredWeights[i]
+			}
+			thresholds[i] -= options.Offset
+		}
+	case AdaptiveSauvola:
+		means, deviations := windowStatistics(lightness, weights, width, height, options.Radius)
+		thresholds = make([]float64, len(means))
+		for i := range thresholds {
+			thresholds[i] = means[i] * (1 + options.K*(deviations[i]/options.R-1))
+		}
+	default:
+		thresholds, _ = windowStatistics(lightness, weights, width, height, options.Radius)
+		for i := range thresholds {
+			thresholds[i] -= options.Offset
+		}
+	}
+
+	bitmap := NewBitmap(bounds)
+	for i := range bitmap.Pix {
+		if weights[i] == 0 {
+			continue
+		}
+		if options.Light {
+			bitmap.Pix[i] = lightness[i] > thresholds[i]
+		} else {
+			bitmap.Pix[i] = lightness[i] < thresholds[i]
+		}
+	}
+	return bitmap
+}
+
+// Compute the weighted mean and standard deviation of the square window around
+// each value, using summed area tables. Windows are clipped to the image.
+// Values are expected to be zero where their weight is.
+func windowStatistics(values, weights []float64, width, height, radius int) (means []float64, deviations []float64) {
+	// Summed area tables have an extra row and column of zeros at the start
+	stride := width + 1
+	sums := make([]float64, stride*(height+1))
+	squares := make([]float64, stride*(height+1))
+	counts := make([]float64, stride*(height+1))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v, w := values[y*width+x], weights[y*width+x]
+			i := (y+1)*stride + x + 1
+			sums[i] = w*v + sums[i-1] + sums[i-stride] - sums[i-stride-1]
+			squares[i] = w*v*v + squares[i-1] + squares[i-stride] - squares[i-stride-1]
+			counts[i] = w + counts[i-1] + counts[i-stride] - counts[i-stride-1]
+		}
+	}
+
+	means = make([]float64, len(values))
+	deviations = make([]float64, len(values))
+	for y := 0; y < height; y++ {
+		top, bottom := maxInt(y-radius, 0), minInt(y+radius+1, height)
+		for x := 0; x < width; x++ {
+			left, right := maxInt(x-radius, 0), minInt(x+radius+1, width)
+			area := func(table []float64) float64 {
+				return table[bottom*stride+right] - table[top*stride+right] - table[bottom*stride+left] + table[top*stride+left]
+			}
+			count := area(counts)
+			if count <= 0 {
+				continue
+			}
+			mean := area(sums) / count
+			variance := area(squares)/count - mean*mean
+			means[y*width+x] = mean
+			deviations[y*width+x] = math.Sqrt(math.Max(variance, 0))
+		}
+	}
+	return means, deviations
+}
+
+// Separable Gaussian blur, renormalizing the kernel where it is clipped by the
+// edges of the image
+func gaussianBlur(values []float64, width, height int, sigma float64) []float64 {
+	radius := int(math.Ceil(sigma * 3))
+	kernel := make([]float64, 2*radius+1)
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+	}
+
+	blurPass := func(input []float64, dx, dy int) []float64 {
+		output := make([]float64, len(input))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				sum, weight := 0.0, 0.0
+				for k, w := range kernel {
+					sx, sy := x+(k-radius)*dx, y+(k-radius)*dy
+					if sx < 0 || sx >= width || sy < 0 || sy >= height {
+						continue
+					}
+					sum += input[sy*width+sx] * w
+					weight += w
+				}
+				output[y*width+x] = sum / weight
+			}
+		}
+		return output
+	}
+	return blurPass(blurPass(values, 1, 0), 0, 1)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,78 @@
+package simpletrace
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOtsuThreshold(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 10, 10))
+	for i := range img.Pix {
+		img.Pix[i] = 200
+		if i%3 == 0 {
+			img.Pix[i] = 90
+		}
+	}
+	threshold := OtsuThreshold(img)
+	assert.GreaterOrEqual(t, threshold, uint8(90))
+	assert.Less(t, threshold, uint8(200))
+
+	assert.True(t, OtsuDarkColorFilledFunc(img)(color.Gray{90}))
+	assert.False(t, OtsuDarkColorFilledFunc(img)(color.Gray{200}))
+	assert.True(t, OtsuLightColorFilledFunc(img)(color.Gray{200}))
+	assert.False(t, OtsuLightColorFilledFunc(img)(color.Gray{90}))
+}
+
+func TestAdaptiveThreshold(t *testing.T) {
+	// A lighting gradient so strong that the ink on the light side is lighter
+	// than the paper on the dark side
+	img := image.NewGray(image.Rect(0, 0, 40, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 40; x++ {
+			value := 60 + x*5
+			if x%8 == 4 && y > 2 && y < 7 {
+				value -= 50
+			}
+			img.SetGray(x, y, color.Gray{uint8(value)})
+		}
+	}
+
+	for _, method := range []AdaptiveMethod{AdaptiveMean, AdaptiveGaussian, AdaptiveSauvola} {
+		bitmap := AdaptiveThreshold(img, AdaptiveThresholdOptions{Method: method, Radius: 3, Offset: 10, K: 0.1})
+		// The clipped windows at the ends of the gradient are skewed, so only check
+		// the middle
+		for y := 0; y < 10; y++ {
+			for x := 2; x < 38; x++ {
+				isInk := x%8 == 4 && y > 2 && y < 7
+				assert.Equal(t, isInk, bitmap.Filled(x, y), "method %d at (%d, %d)", method, x, y)
+			}
+		}
+	}
+}
+
+func TestAdaptiveThresholdIgnoresTransparentPixels(t *testing.T) {
+	// Paper on the right half, with a column of ink right next to the
+	// transparent left half
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 10))
+	for y := 0; y < 10; y++ {
+		for x := 10; x < 20; x++ {
+			value := uint8(120)
+			if x == 10 {
+				value = 70
+			}
+			img.SetNRGBA(x, y, color.NRGBA{value, value, value, 0xff})
+		}
+	}
+
+	for _, method := range []AdaptiveMethod{AdaptiveMean, AdaptiveGaussian, AdaptiveSauvola} {
+		bitmap := AdaptiveThreshold(img, AdaptiveThresholdOptions{Method: method, Radius: 3, Offset: 10, K: 0.1})
+		for y := 0; y < 10; y++ {
+			for x := 0; x < 20; x++ {
+				assert.Equal(t, x == 10, bitmap.Filled(x, y), "method %d at (%d, %d)", method, x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package simpletrace
+
+import "sort"
+
+// A polygon from a trace result, along with the polygons nested directly inside
+// it. Because traced polygons never touch, each polygon is either completely
+// inside another or completely outside it, so nesting forms a tree.
+type polygonNode struct {
+	index    int     // Index of the polygon in the original trace result
+	area     float64 // Absolute area of the polygon
+	children []*polygonNode
+}
+
+// Arrange a trace result into a nesting tree, returning the outermost polygons
+func buildPolygonTree(polygons []Polygon) []*polygonNode {
+	nodes := make([]*polygonNode, len(polygons))
+	for i, polygon := range polygons {
+		area := SignedAreaOfPolygon(polygon)
+		if area < 0 {
+			area = -area
+		}
+		nodes[i] = &polygonNode{index: i, area: area}
+	}
+
+	// A polygon can only be inside a larger polygon, so by inserting the largest
+	// first, every polygon's ancestors are already in the tree when it arrives.
+	sorted := make([]*polygonNode, len(nodes))
+	copy(sorted, nodes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].area > sorted[j].area
+	})
+
+	var roots []*polygonNode
+	for _, node := range sorted {
+		polygon := polygons[node.index]
+		if len(polygon) == 0 {
+			continue
+		}
+		// Any vertex will do for the containment test, since polygons never touch
+		probe := polygon[0]
+
+		siblings := &roots
+		for {
+			var container *polygonNode
+			for _, candidate := range *siblings {
+				if pointInPolygon(probe, polygons[candidate.index]) {
+					container = candidate
+					break
+				}
+			}
+			if container == nil {
+				break
+			}
+			siblings = &container.children
+		}
+		*siblings = append(*siblings, node)
+	}
+	return roots
+}
+
+// Even-odd test for whether a point is inside a polygon
+func pointInPolygon(p Point, polygon Polygon) bool {
+	inside := false
+	n := len(polygon)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) {
+			crossingX := a.X + (p.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+			if p.X < crossingX {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
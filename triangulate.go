@@ -0,0 +1,417 @@
+package simpletrace
+
+import (
+	"math"
+	"sort"
+)
+
+// An indexed triangle mesh. Each triangle is three indices into Vertices, wound
+// the same way as the filled polygons it came from, so in the default winding
+// convention every triangle has a positive signed area.
+type Mesh struct {
+	Vertices  []Point
+	Triangles [][3]int
+}
+
+type TriangulationMode uint8
+
+const (
+	// Ear clipping, which is fast, but tends to produce long, thin triangles
+	TriangulateEarClipping = TriangulationMode(iota)
+	// Ear clipping followed by edge flips until the triangulation is a
+	// constrained Delaunay triangulation, which maximizes the smallest angles
+	// while keeping the polygon edges
+	TriangulateDelaunay
+)
+
+// Triangulate a trace result into a single mesh. Filled polygons (counter
+// clockwise) are triangulated along with the holes (clockwise) directly inside
+// them, and islands inside holes are triangulated separately.
+func Triangulate(polygons []Polygon, mode TriangulationMode) Mesh {
+	return FilledCounterclockwise.Triangulate(polygons, mode)
+}
+
+// Triangulate polygons in this winding convention. The triangles are wound like
+// filled polygons in this convention.
+func (c WindingConvention) Triangulate(polygons []Polygon, mode TriangulationMode) Mesh {
+	polygons = c.Convert(polygons)
+	var mesh Mesh
+	var visit func(nodes []*polygonNode)
+	visit = func(nodes []*polygonNode) {
+		for _, node := range nodes {
+			if SignedAreaOfPolygon(polygons[node.index]) > 0 {
+				var holes []Polygon
+				for _, child := range node.children {
+					holes = append(holes, polygons[child.index])
+				}
+				mesh.addShape(polygons[node.index], holes, mode)
+			}
+			visit(node.children)
+		}
+	}
+	visit(buildPolygonTree(polygons))
+	mesh.wind(c)
+	return mesh
+}
+
+// Wind the triangles of a mesh built in the default convention to suit another
+// convention
+func (mesh *Mesh) wind(convention WindingConvention) {
+	if convention != FilledClockwise {
+		return
+	}
+	for i, triangle := range mesh.Triangles {
+		mesh.Triangles[i] = [3]int{triangle[0], triangle[2], triangle[1]}
+	}
+}
+
+// Triangulate a filled polygon with holes, and add it to the mesh
+func (mesh *Mesh) addShape(outer Polygon, holes []Polygon, mode TriangulationMode) {
+	var constrainedEdges map[[2]int]bool
+	if mode == TriangulateDelaunay {
+		constrainedEdges = make(map[[2]int]bool)
+	}
+
+	// Add a ring of vertices to the mesh, returning their indices
+	addRing := func(ring Polygon) []int {
+		indices := make([]int, len(ring))
+		for i, p := range ring {
+			indices[i] = len(mesh.Vertices)
+			mesh.Vertices = append(mesh.Vertices, p)
+		}
+		if constrainedEdges != nil {
+			for i := range indices {
+				constrainedEdges[edgeKey(indices[i], indices[(i+1)%len(indices)])] = true
+			}
+		}
+		return indices
+	}
+
+	ring := addRing(outer)
+	var holeRings [][]int
+	for _, hole := range holes {
+		holeRings = append(holeRings, addRing(hole))
+	}
+	ring = mesh.bridgeHoles(ring, holeRings)
+
+	firstTriangle := len(mesh.Triangles)
+	mesh.clipEars(ring)
+	if mode == TriangulateDelaunay {
+		mesh.flipToDelaunay(firstTriangle, constrainedEdges)
+	}
+}
+
+// Merge each hole into the outer ring by cutting a zero width bridge from the
+// hole to a visible vertex of the ring. The result is a single ring which
+// visits the bridge vertices twice.
+//
+// This follows David Eberly's "Triangulation by Ear Clipping". Holes are
+// merged from right to left, and each bridge runs rightward from the hole's
+// rightmost vertex.
+func (mesh *Mesh) bridgeHoles(ring []int, holes [][]int) []int {
+	rightmost := func(hole []int) int {
+		best := 0
+		for i, index := range hole {
+			p, bestPoint := mesh.Vertices[index], mesh.Vertices[hole[best]]
+			if p.X > bestPoint.X || (p.X == bestPoint.X && p.Y < bestPoint.Y) {
+				best = i
+			}
+		}
+		return best
+	}
+
+	sort.SliceStable(holes, func(i, j int) bool {
+		return mesh.Vertices[holes[i][rightmost(holes[i])]].X > mesh.Vertices[holes[j][rightmost(holes[j])]].X
+	})
+
+	for _, hole := range holes {
+		start := rightmost(hole)
+		bridgeTo := mesh.findBridge(ring, mesh.Vertices[hole[start]])
+		if bridgeTo < 0 {
+			// The hole isn't inside the ring, so it can't be bridged
+			continue
+		}
+
+		merged := make([]int, 0, len(ring)+len(hole)+2)
+		merged = append(merged, ring[:bridgeTo+1]...)
+		for i := 0; i <= len(hole); i++ {
+			merged = append(merged, hole[(start+i)%len(hole)])
+		}
+		merged = append(merged, ring[bridgeTo:]...)
+		ring = merged
+	}
+	return ring
+}
+
+// Find the position in the ring of a vertex which can be joined to m without
+// crossing any edges, or -1 if there is none.
+func (mesh *Mesh) findBridge(ring []int, m Point) int {
+	n := len(ring)
+	at := func(i int) Point { return mesh.Vertices[ring[(i+n)%n]] }
+
+	// Cast a ray to the right, and find the nearest edge it hits on its way out
+	// of the ring's interior. Those edges run upward, since the interior is on
+	// their left.
+	hit := -1
+	hitX := math.Inf(1)
+	for i := 0; i < n; i++ {
+		a, b := at(i), at(i+1)
+		if !(a.Y <= m.Y && m.Y <= b.Y && a.Y < b.Y) {
+			continue
+		}
+		x := a.X + (m.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+		if x >= m.X && x < hitX {
+			hitX = x
+			hit = i
+		}
+	}
+	if hit < 0 {
+		return -1
+	}
+
+	// If the ray hits a vertex, that vertex is visible
+	a, b := at(hit), at(hit+1)
+	if a.X == hitX && a.Y == m.Y {
+		return mesh.bridgeOccurrence(ring, hit, m)
+	}
+	if b.X == hitX && b.Y == m.Y {
+		return mesh.bridgeOccurrence(ring, (hit+1)%n, m)
+	}
+
+	// Otherwise, the rightmost end of the edge is a candidate, unless some
+	// reflex vertex inside the triangle between m, the hit, and the candidate
+	// blocks the view. In that case, the blocking vertex closest in angle to the
+	// ray is visible.
+	candidate := hit
+	if b.X > a.X {
+		candidate = (hit + 1) % n
+	}
+	hitPoint := Point{hitX, m.Y}
+	p := at(candidate)
+	bestAngle := math.Inf(1)
+	bestDistance := math.Inf(1)
+	for i := 0; i < n; i++ {
+		r := at(i)
+		if r == p || !isReflex(at(i-1), r, at(i+1)) || !pointInTriangle(r, m, hitPoint, p) {
+			continue
+		}
+		angle := math.Abs(math.Atan2(r.Y-m.Y, r.X-m.X))
+		distance := m.DistanceTo(r)
+		if angle < bestAngle || (angle == bestAngle && distance < bestDistance) {
+			candidate = i
+			bestAngle = angle
+			bestDistance = distance
+		}
+	}
+	return mesh.bridgeOccurrence(ring, candidate, m)
+}
+
+// A vertex may appear more than once in the ring if an earlier bridge ends at
+// it. Pick the occurrence whose interior angle contains the direction to m, so
+// that the bridges don't cross.
+func (mesh *Mesh) bridgeOccurrence(ring []int, position int, m Point) int {
+	n := len(ring)
+	for i := 0; i < n; i++ {
+		if ring[i] != ring[position] {
+			continue
+		}
+		prev, v, next := mesh.Vertices[ring[(i+n-1)%n]], mesh.Vertices[ring[i]], mesh.Vertices[ring[(i+1)%n]]
+		if inCone(prev, v, next, m) {
+			return i
+		}
+	}
+	return position
+}
+
+// Whether the direction from v to m points into the interior of the ring at v,
+// where prev and next are its neighbors and the interior is on the left.
+func inCone(prev, v, next, m Point) bool {
+	leftOfOutgoing := cross(v, next, m) > 0
+	leftOfIncoming := cross(prev, v, m) > 0
+	if isReflex(prev, v, next) {
+		return leftOfOutgoing || leftOfIncoming
+	}
+	return leftOfOutgoing && leftOfIncoming
+}
+
+// Triangulate a ring by repeatedly cutting off ears, appending the triangles to
+// the mesh
+func (mesh *Mesh) clipEars(ring []int) {
+	n := len(ring)
+	if n < 3 {
+		return
+	}
+	prev := make([]int, n)
+	next := make([]int, n)
+	for i := range ring {
+		prev[i] = (i + n - 1) % n
+		next[i] = (i + 1) % n
+	}
+	at := func(i int) Point { return mesh.Vertices[ring[i]] }
+
+	isEar := func(i int) bool {
+		a, b, c := at(prev[i]), at(i), at(next[i])
+		if cross(a, b, c) <= 0 {
+			return false
+		}
+		// Only reflex vertices can be inside an ear. Straight vertices are
+		// checked too, since one lying on the new diagonal would leave a
+		// T-junction in the mesh.
+		for j := next[next[i]]; j != prev[i]; j = next[j] {
+			p := at(j)
+			if p == a || p == b || p == c {
+				continue
+			}
+			if cross(at(prev[j]), p, at(next[j])) <= 0 && pointInTriangle(p, a, b, c) {
+				return false
+			}
+		}
+		return true
+	}
+
+	remaining := n
+	current := 0
+	stalled := 0
+	for remaining > 3 {
+		if stalled < remaining && !isEar(current) {
+			current = next[current]
+			stalled++
+			continue
+		}
+		// If a whole lap finds no ears, the ring must be degenerate, so cut off
+		// whatever vertex comes next to guarantee progress.
+		if cross(at(prev[current]), at(current), at(next[current])) > 0 {
+			mesh.Triangles = append(mesh.Triangles, [3]int{ring[prev[current]], ring[current], ring[next[current]]})
+		}
+		next[prev[current]] = next[current]
+		prev[next[current]] = prev[current]
+		current = next[current]
+		remaining--
+		stalled = 0
+	}
+	if cross(at(prev[current]), at(current), at(next[current])) > 0 {
+		mesh.Triangles = append(mesh.Triangles, [3]int{ring[prev[current]], ring[current], ring[next[current]]})
+	}
+}
+
+// Flip edges of the triangles from firstTriangle onward until none of them has
+// a neighbor inside its circumcircle, without flipping any constrained edges.
+func (mesh *Mesh) flipToDelaunay(firstTriangle int, constrainedEdges map[[2]int]bool) {
+	// Map each edge to the triangles on either side of it
+	edgeTriangles := make(map[[2]int][]int)
+	addTriangle := func(t int) {
+		triangle := mesh.Triangles[t]
+		for i := 0; i < 3; i++ {
+			key := edgeKey(triangle[i], triangle[(i+1)%3])
+			edgeTriangles[key] = append(edgeTriangles[key], t)
+		}
+	}
+	removeTriangle := func(t int) {
+		triangle := mesh.Triangles[t]
+		for i := 0; i < 3; i++ {
+			key := edgeKey(triangle[i], triangle[(i+1)%3])
+			triangles := edgeTriangles[key]
+			for j, other := range triangles {
+				if other == t {
+					edgeTriangles[key] = append(triangles[:j:j], triangles[j+1:]...)
+					break
+				}
+			}
+		}
+	}
+
+	var queue [][2]int
+	for t := firstTriangle; t < len(mesh.Triangles); t++ {
+		addTriangle(t)
+	}
+	for key := range edgeTriangles {
+		queue = append(queue, key)
+	}
+	// Visit edges in a stable order so that results are reproducible
+	sort.Slice(queue, func(i, j int) bool {
+		return queue[i][0] < queue[j][0] || (queue[i][0] == queue[j][0] && queue[i][1] < queue[j][1])
+	})
+
+	// Lawson's algorithm always terminates, but guard against floating point
+	// trouble causing a flip cycle
+	limit := len(queue) * len(queue)
+	for flips := 0; len(queue) > 0 && flips < limit; {
+		key := queue[0]
+		queue = queue[1:]
+		triangles := edgeTriangles[key]
+		if constrainedEdges[key] || len(triangles) != 2 {
+			continue
+		}
+
+		// Rotate both triangles so that they start with the shared edge, giving
+		// a, b, c and b, a, d.
+		t1, t2 := triangles[0], triangles[1]
+		a, b, c := rotateTriangleToEdge(mesh.Triangles[t1], key)
+		_, _, d := rotateTriangleToEdge(mesh.Triangles[t2], key)
+
+		pa, pb, pc, pd := mesh.Vertices[a], mesh.Vertices[b], mesh.Vertices[c], mesh.Vertices[d]
+		if inCircle(pa, pb, pc, pd) <= 0 {
+			continue
+		}
+		// The flipped triangles must both keep their winding, or the quad isn't
+		// convex
+		if cross(pa, pd, pc) <= 0 || cross(pd, pb, pc) <= 0 {
+			continue
+		}
+
+		removeTriangle(t1)
+		removeTriangle(t2)
+		mesh.Triangles[t1] = [3]int{a, d, c}
+		mesh.Triangles[t2] = [3]int{d, b, c}
+		addTriangle(t1)
+		addTriangle(t2)
+		queue = append(queue, edgeKey(a, d), edgeKey(d, b), edgeKey(b, c), edgeKey(c, a))
+		flips++
+	}
+}
+
+// Rotate a triangle so that the given edge comes first. The edge may be in
+// either direction.
+func rotateTriangleToEdge(triangle [3]int, key [2]int) (int, int, int) {
+	for i := 0; i < 3; i++ {
+		a, b, c := triangle[i], triangle[(i+1)%3], triangle[(i+2)%3]
+		if edgeKey(a, b) == key {
+			return a, b, c
+		}
+	}
+	panic("Triangle does not contain edge")
+}
+
+func edgeKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// Twice the signed area of the triangle abc. Positive when the triangle is
+// wound like a filled polygon.
+func cross(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+func isReflex(prev, v, next Point) bool {
+	return cross(prev, v, next) < 0
+}
+
+// Whether p is inside or on the edge of the triangle abc, which must be wound
+// like a filled polygon
+func pointInTriangle(p, a, b, c Point) bool {
+	return cross(a, b, p) >= 0 && cross(b, c, p) >= 0 && cross(c, a, p) >= 0
+}
+
+// Positive if d is inside the circumcircle of the triangle abc, which must be
+// wound like a filled polygon
+func inCircle(a, b, c, d Point) float64 {
+	adx, ady := a.X-d.X, a.Y-d.Y
+	bdx, bdy := b.X-d.X, b.Y-d.Y
+	cdx, cdy := c.X-d.X, c.Y-d.Y
+	return (adx*adx+ady*ady)*(bdx*cdy-cdx*bdy) -
+		(bdx*bdx+bdy*bdy)*(adx*cdy-cdx*ady) +
+		(cdx*cdx+cdy*cdy)*(adx*bdy-bdx*ady)
+}
@@ -0,0 +1,57 @@
+package simpletrace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriangulate(t *testing.T) {
+	img := imageFromRows(
+		"................",
+		".XXXXXXXXXXXXXX.",
+		".XX..XXXXXXXXXX.",
+		".XX..XX.....XXX.",
+		".XXXXXX..X..XXX.",
+		".XXXXXX.....X.X.",
+		".XXXXXXXXXXXXXX.",
+		"................",
+		"...........XXX..",
+		"..........XX....",
+		"................",
+	)
+	polygons := TraceImage(img, OpacityColorFilledFunc)
+	totalArea := 0.0
+	for _, polygon := range polygons {
+		totalArea += SignedAreaOfPolygon(polygon)
+	}
+
+	for _, mode := range []TriangulationMode{TriangulateEarClipping, TriangulateDelaunay} {
+		mesh := Triangulate(polygons, mode)
+		meshArea := 0.0
+		for _, triangle := range mesh.Triangles {
+			area := cross(mesh.Vertices[triangle[0]], mesh.Vertices[triangle[1]], mesh.Vertices[triangle[2]]) / 2
+			assert.Greater(t, area, 0.0)
+			meshArea += area
+		}
+		assert.InDelta(t, totalArea, meshArea, 1e-9, "mode %d", mode)
+	}
+}
+
+func TestTriangulateDelaunay(t *testing.T) {
+	// A long thin fan, where ear clipping produces slivers
+	polygon := Polygon{{0, 0}, {10, 0}, {10, 1}, {8, 1.2}, {6, 1.3}, {4, 1.3}, {2, 1.2}, {0, 1}}
+	mesh := Triangulate([]Polygon{polygon}, TriangulateDelaunay)
+	assert.Len(t, mesh.Triangles, len(polygon)-2)
+
+	// No vertex may be inside the circumcircle of a triangle it can see across
+	// an edge, which for a convex polygon is every triangle
+	for _, triangle := range mesh.Triangles {
+		for i, p := range mesh.Vertices {
+			if i == triangle[0] || i == triangle[1] || i == triangle[2] {
+				continue
+			}
+			assert.LessOrEqual(t, inCircle(mesh.Vertices[triangle[0]], mesh.Vertices[triangle[1]], mesh.Vertices[triangle[2]], p), 1e-9)
+		}
+	}
+}
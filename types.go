@@ -74,3 +74,7 @@ func (p Point) UnitVectorTo(other Point) Point {
 	result.Y /= len
 	return result
 }
+
+func (p Point) DistanceTo(other Point) float64 {
+	return math.Hypot(other.X-p.X, other.Y-p.Y)
+}
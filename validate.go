@@ -0,0 +1,250 @@
+package simpletrace
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// The kind of problem found by Validate
+type ViolationKind uint8
+
+const (
+	// The polygon has fewer than three vertices, or no area
+	ViolationDegenerate = ViolationKind(iota)
+	// Two consecutive vertices of the polygon are the same point
+	ViolationRepeatedVertex
+	// Two edges of the polygon cross or touch, other than consecutive edges
+	// meeting at their shared vertex
+	ViolationSelfIntersection
+	// The polygon is wound the wrong way for its nesting depth. Outermost
+	// polygons and islands must be wound as filled polygons, and holes the
+	// other way.
+	ViolationWrongWinding
+	// Edges of two different polygons cross or touch
+	ViolationIntersection
+)
+
+func (k ViolationKind) String() string {
+	switch k {
+	case ViolationDegenerate:
+		return "degenerate polygon"
+	case ViolationRepeatedVertex:
+		return "repeated vertex"
+	case ViolationSelfIntersection:
+		return "self-intersection"
+	case ViolationWrongWinding:
+		return "wrong winding"
+	case ViolationIntersection:
+		return "intersection"
+	}
+	return "unknown violation"
+}
+
+// A problem with a trace result, found by Validate
+type Violation struct {
+	Kind ViolationKind
+	// The index of the offending polygon
+	Polygon int
+	// The index of the edge where the problem is, where edge i runs from vertex i
+	// to vertex i+1. This is -1 for problems with the polygon as a whole.
+	Edge int
+	// For self-intersections and intersections, the polygon and edge that Edge
+	// meets. Otherwise these are -1.
+	OtherPolygon int
+	OtherEdge    int
+	// Where the problem is
+	Point Point
+}
+
+func (v Violation) Error() string {
+	switch v.Kind {
+	case ViolationSelfIntersection:
+		return fmt.Sprintf("%v: polygon %d edges %d and %d meet at %v", v.Kind, v.Polygon, v.Edge, v.OtherEdge, v.Point)
+	case ViolationIntersection:
+		return fmt.Sprintf("%v: polygon %d edge %d meets polygon %d edge %d at %v", v.Kind, v.Polygon, v.Edge, v.OtherPolygon, v.OtherEdge, v.Point)
+	case ViolationRepeatedVertex:
+		return fmt.Sprintf("%v: polygon %d edge %d has no length at %v", v.Kind, v.Polygon, v.Edge, v.Point)
+	}
+	return fmt.Sprintf("%v: polygon %d", v.Kind, v.Polygon)
+}
+
+// Check that a trace result keeps the guarantees that the tracer makes:
+//
+//   - Every polygon is simple, with at least three distinct vertices and no
+//     edges that cross or touch
+//   - Polygons are wound by nesting depth, so that outermost polygons are
+//     counterclockwise, the holes inside them clockwise, the islands inside
+//     those counterclockwise, and so on
+//   - No two polygons cross or touch each other
+//
+// This returns every violation found, or nil if the result is valid. It is
+// useful for checking polygons which have been edited or built by hand before
+// handing them to something that relies on these guarantees.
+func Validate(polygons []Polygon) []Violation {
+	return FilledCounterclockwise.Validate(polygons)
+}
+
+// Validate polygons in this winding convention. In the clockwise convention,
+// the windings required of each nesting depth are the other way around.
+func (c WindingConvention) Validate(polygons []Polygon) []Violation {
+	var violations []Violation
+	valid := make([]bool, len(polygons))
+	for i, polygon := range polygons {
+		valid[i] = true
+		if len(polygon) < 3 || polygon.SignedArea() == 0 {
+			violations = append(violations, Violation{
+				Kind: ViolationDegenerate, Polygon: i, Edge: -1, OtherPolygon: -1, OtherEdge: -1,
+			})
+			valid[i] = false
+			continue
+		}
+		for j, p := range polygon {
+			if p == polygon[(j+1)%len(polygon)] {
+				violations = append(violations, Violation{
+					Kind: ViolationRepeatedVertex, Polygon: i, Edge: j, OtherPolygon: -1, OtherEdge: -1, Point: p,
+				})
+				valid[i] = false
+			}
+		}
+	}
+
+	violations = append(violations, edgeViolations(polygons, valid)...)
+
+	// Nesting depth is only meaningful for the polygons that are well formed
+	var wellFormed []Polygon
+	var indices []int
+	for i, polygon := range polygons {
+		if valid[i] {
+			wellFormed = append(wellFormed, polygon)
+			indices = append(indices, i)
+		}
+	}
+	var checkWinding func(nodes []*polygonNode, filled bool)
+	checkWinding = func(nodes []*polygonNode, filled bool) {
+		for _, node := range nodes {
+			if c.IsFilled(wellFormed[node.index]) != filled {
+				violations = append(violations, Violation{
+					Kind: ViolationWrongWinding, Polygon: indices[node.index], Edge: -1, OtherPolygon: -1, OtherEdge: -1,
+					Point: wellFormed[node.index][0],
+				})
+			}
+			checkWinding(node.children, !filled)
+		}
+	}
+	checkWinding(buildPolygonTree(wellFormed), true)
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Polygon < violations[j].Polygon
+	})
+	return violations
+}
+
+type validationEdge struct {
+	polygon, index int
+	a, b           Point
+}
+
+// Find every pair of edges that meet where they shouldn't, sweeping along x so
+// that only edges with overlapping extents are compared
+func edgeViolations(polygons []Polygon, valid []bool) []Violation {
+	var edges []validationEdge
+	for i, polygon := range polygons {
+		if !valid[i] {
+			continue
+		}
+		for j, a := range polygon {
+			edges = append(edges, validationEdge{i, j, a, polygon[(j+1)%len(polygon)]})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return math.Min(edges[i].a.X, edges[i].b.X) < math.Min(edges[j].a.X, edges[j].b.X)
+	})
+
+	var violations []Violation
+	var active []validationEdge
+	for _, edge := range edges {
+		minX := math.Min(edge.a.X, edge.b.X)
+		// Drop edges which end before this one starts
+		kept := active[:0]
+		for _, other := range active {
+			if math.Max(other.a.X, other.b.X) >= minX {
+				kept = append(kept, other)
+			}
+		}
+		active = kept
+
+		for _, other := range active {
+			if violation, ok := compareEdges(polygons, other, edge); ok {
+				violations = append(violations, violation)
+			}
+		}
+		active = append(active, edge)
+	}
+	return violations
+}
+
+func compareEdges(polygons []Polygon, first, second validationEdge) (Violation, bool) {
+	if first.polygon > second.polygon || (first.polygon == second.polygon && first.index > second.index) {
+		first, second = second, first
+	}
+	violation := Violation{
+		Kind:         ViolationIntersection,
+		Polygon:      first.polygon,
+		Edge:         first.index,
+		OtherPolygon: second.polygon,
+		OtherEdge:    second.index,
+	}
+
+	if first.polygon == second.polygon {
+		violation.Kind = ViolationSelfIntersection
+		n := len(polygons[first.polygon])
+		var shared, before, after Point
+		switch {
+		case second.index == first.index+1:
+			shared, before, after = first.b, first.a, second.b
+		case first.index == 0 && second.index == n-1:
+			shared, before, after = first.a, second.a, first.b
+		default:
+			if !segmentsIntersect(first.a, first.b, second.a, second.b) {
+				return Violation{}, false
+			}
+			violation.Point = segmentContactPoint(first.a, first.b, second.a, second.b)
+			return violation, true
+		}
+		// Consecutive edges always meet at their shared vertex, so they only
+		// intersect if the outline doubles back along itself
+		inX, inY := shared.X-before.X, shared.Y-before.Y
+		outX, outY := after.X-shared.X, after.Y-shared.Y
+		if cross(before, shared, after) != 0 || inX*outX+inY*outY >= 0 {
+			return Violation{}, false
+		}
+		violation.Point = shared
+		return violation, true
+	}
+
+	if !segmentsIntersect(first.a, first.b, second.a, second.b) {
+		return Violation{}, false
+	}
+	violation.Point = segmentContactPoint(first.a, first.b, second.a, second.b)
+	return violation, true
+}
+
+// A point where two intersecting segments meet. For segments that overlap along
+// a line, this is any point in the overlap.
+func segmentContactPoint(a, b, c, d Point) Point {
+	denominator := (b.X-a.X)*(d.Y-c.Y) - (b.Y-a.Y)*(d.X-c.X)
+	if denominator != 0 {
+		t := ((c.X-a.X)*(d.Y-c.Y) - (c.Y-a.Y)*(d.X-c.X)) / denominator
+		return Point{a.X + (b.X-a.X)*t, a.Y + (b.Y-a.Y)*t}
+	}
+	for _, p := range []Point{a, b} {
+		if onSegment(c, d, p) {
+			return p
+		}
+	}
+	if onSegment(a, b, c) {
+		return c
+	}
+	return d
+}
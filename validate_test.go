@@ -0,0 +1,88 @@
+package simpletrace
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	outer := squarePolygon(0, 0, 10)
+	hole := squarePolygon(3, 3, 4).Reverse()
+	assert.Empty(t, Validate([]Polygon{outer, hole}))
+
+	// Holes must be clockwise
+	violations := Validate([]Polygon{outer, hole.Reverse()})
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, ViolationWrongWinding, violations[0].Kind)
+		assert.Equal(t, 1, violations[0].Polygon)
+	}
+
+	// Reversing everything is only valid in the other convention
+	reversed := []Polygon{outer.Reverse(), hole.Reverse()}
+	assert.Len(t, Validate(reversed), 2)
+	assert.Empty(t, FilledClockwise.Validate(reversed))
+
+	// A bowtie crosses itself
+	bowtie := Polygon{{0, 0}, {2, 2}, {2, 0}, {0, 3}}
+	violations = Validate([]Polygon{bowtie})
+	if assert.NotEmpty(t, violations) {
+		assert.Equal(t, ViolationSelfIntersection, violations[0].Kind)
+		assert.InDelta(t, 1.2, violations[0].Point.X, 1e-9)
+		assert.InDelta(t, 1.2, violations[0].Point.Y, 1e-9)
+	}
+
+	// Squares that share a corner touch
+	violations = Validate([]Polygon{squarePolygon(0, 0, 1), squarePolygon(1, 1, 1)})
+	if assert.NotEmpty(t, violations) {
+		assert.Equal(t, ViolationIntersection, violations[0].Kind)
+		assert.Equal(t, Point{1, 1}, violations[0].Point)
+	}
+
+	violations = Validate([]Polygon{{{0, 0}, {1, 0}, {1, 0}, {0, 1}}, {{0, 0}, {1, 1}}})
+	if assert.Len(t, violations, 2) {
+		assert.Equal(t, ViolationRepeatedVertex, violations[0].Kind)
+		assert.Equal(t, ViolationDegenerate, violations[1].Kind)
+	}
+}
+
+func randomBitmap(random *rand.Rand, width, height int, density float64) *Bitmap {
+	bitmap := NewBitmap(image.Rect(0, 0, width, height))
+	for i := range bitmap.Pix {
+		bitmap.Pix[i] = random.Float64() < density
+	}
+	return bitmap
+}
+
+func TestTraceRandomBitmapsIsValid(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		bitmap := randomBitmap(random, 1+random.Intn(24), 1+random.Intn(24), random.Float64())
+		polygons := TraceImage(bitmap, OpacityColorFilledFunc)
+		if violations := Validate(polygons); !assert.Empty(t, violations, "bitmap %d", i) {
+			return
+		}
+	}
+}
+
+func FuzzTraceIsValid(f *testing.F) {
+	f.Add(uint8(3), []byte{0b101, 0b010, 0b101})
+	f.Add(uint8(4), []byte{0xff, 0x0f, 0xf0, 0x96})
+	f.Fuzz(func(t *testing.T, width uint8, rows []byte) {
+		if width == 0 || width > 8 || len(rows) > 64 {
+			return
+		}
+		bitmap := NewBitmap(image.Rect(0, 0, int(width), len(rows)))
+		for y, row := range rows {
+			for x := 0; x < int(width); x++ {
+				bitmap.SetFilled(x, y, row&(1<<x) != 0)
+			}
+		}
+		polygons := TraceImage(bitmap, OpacityColorFilledFunc)
+		for _, violation := range Validate(polygons) {
+			t.Error(violation)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package simpletrace
+
+import "math"
+
+// Which way filled polygons and holes are wound.
+//
+// Helpers that take a trace result, such as the boolean operations, Validate
+// and Triangulate, assume the default convention. Each of them has a
+// counterpart which takes the convention explicitly, either as a method of the
+// convention, as in FilledClockwise.Validate(polygons), or as a Winding option
+// for helpers which take options. Any polygons they return use the same
+// convention.
+type WindingConvention uint8
+
+const (
+	// Filled polygons have a positive signed area and holes a negative one. This
+	// is counterclockwise in the usual sense, as in the README, although with y
+	// pointing down it looks clockwise on screen. This is the default, and the
+	// convention used internally.
+	FilledCounterclockwise = WindingConvention(iota)
+	// Filled polygons have a negative signed area and holes a positive one
+	FilledClockwise
+)
+
+// Guess which convention a trace result uses, from the sign of its largest
+// polygon. An empty result is taken to use the default convention.
+//
+// Nothing calls this for you, since it guesses wrong for results whose
+// outermost polygons are wound the wrong way, which is exactly what Validate
+// and RepairPolygons are for. Use it for polygons of unknown origin, as in
+// DetectWinding(polygons).Triangulate(polygons, mode).
+func DetectWinding(polygons []Polygon) WindingConvention {
+	largest := 0.0
+	for _, polygon := range polygons {
+		if area := polygon.SignedArea(); math.Abs(area) > math.Abs(largest) {
+			largest = area
+		}
+	}
+	if largest < 0 {
+		return FilledClockwise
+	}
+	return FilledCounterclockwise
+}
+
+func (c WindingConvention) IsFilled(p Polygon) bool {
+	if c == FilledClockwise {
+		return p.SignedArea() < 0
+	}
+	return p.SignedArea() > 0
+}
+
+func (c WindingConvention) IsHole(p Polygon) bool {
+	if c == FilledClockwise {
+		return p.SignedArea() > 0
+	}
+	return p.SignedArea() < 0
+}
+
+// Convert polygons from the default convention to this one. Converting is just
+// reversing every polygon or not, so this also converts back to the default
+// convention. Polygons are only copied if they need reversing.
+func (c WindingConvention) Convert(polygons []Polygon) []Polygon {
+	if c != FilledClockwise || polygons == nil {
+		return polygons
+	}
+	converted := make([]Polygon, len(polygons))
+	for i, polygon := range polygons {
+		converted[i] = polygon.Reverse()
+	}
+	return converted
+}
+
+// Convert a single polygon from the default convention to this one
+func (c WindingConvention) convert(polygon Polygon) Polygon {
+	if c != FilledClockwise {
+		return polygon
+	}
+	return polygon.Reverse()
+}
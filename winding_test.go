@@ -0,0 +1,73 @@
+package simpletrace
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindingConvention(t *testing.T) {
+	img := imageFromRows(
+		"..........",
+		".XXXXXXXX.",
+		".X......X.",
+		".X.XX...X.",
+		".X.XX...X.",
+		".X......X.",
+		".XXXXXXXX.",
+		"..........",
+	)
+	clockwise := TraceImageWithOptions(img, OpacityColorFilledFunc, TraceOptions{Winding: FilledClockwise})
+	assert.Len(t, clockwise, 3)
+	assert.Equal(t, FilledClockwise, DetectWinding(clockwise))
+	for _, polygon := range clockwise {
+		assert.Equal(t, polygon.IsHole(), FilledClockwise.IsFilled(polygon))
+	}
+
+	// The same trace as the default convention, with every polygon reversed
+	counterclockwise := TraceImage(img, OpacityColorFilledFunc)
+	assert.Equal(t, FilledCounterclockwise, DetectWinding(counterclockwise))
+	assert.Equal(t, FilledClockwise.Convert(counterclockwise), clockwise)
+
+	// Helpers read the convention they are given
+	assert.Empty(t, FilledClockwise.Validate(clockwise))
+	assert.NotEmpty(t, Validate(clockwise))
+	assert.Equal(t, 1, FilledClockwise.EulerNumber(clockwise))
+	assert.Len(t, FilledClockwise.ShapesFromPolygons(clockwise), 2)
+	assert.InDelta(t, DescribeShapes(counterclockwise)[0].Area, FilledClockwise.DescribeShapes(clockwise)[0].Area, 1e-9)
+	assert.False(t, FilledClockwise.ContainsPoint(clockwise, Point{2, 2}))
+	assert.True(t, FilledClockwise.ContainsPoint(clockwise, Point{1, 1}))
+	assert.Equal(t, RasterizeBitmap(counterclockwise, img.Bounds()), FilledClockwise.RasterizeBitmap(clockwise, img.Bounds()))
+	assert.Equal(t,
+		RasterizeAlpha(counterclockwise, img.Bounds(), RasterizeOptions{}),
+		RasterizeAlpha(clockwise, img.Bounds(), RasterizeOptions{Winding: FilledClockwise}))
+	bitmap := BitmapFromImage(img, OpacityColorFilledFunc)
+	assert.Equal(t, MeasureFidelity(bitmap, counterclockwise), FilledClockwise.MeasureFidelity(bitmap, clockwise))
+	assert.Equal(t, 1.0, FilledClockwise.MeasureFidelity(bitmap, clockwise).IoU)
+	_, err := FilledClockwise.ToFixed(clockwise, 2)
+	assert.NoError(t, err)
+
+	// And return polygons in the same convention
+	assert.Empty(t, FilledClockwise.Validate(FilledClockwise.Union(clockwise, nil)))
+	assert.Empty(t, FilledClockwise.Validate(Offset(clockwise, 0.5, OffsetOptions{Winding: FilledClockwise})))
+	assert.Empty(t, FilledClockwise.Validate(FilledClockwise.RepairPolygons(clockwise, 0)))
+	assert.True(t, FilledClockwise.ConvexHull(clockwise...).SignedArea() < 0)
+	assert.True(t, FilledClockwise.ConcaveHull(2, 0, clockwise...).SignedArea() < 0)
+	for _, piece := range FilledClockwise.DecomposeConvex(clockwise, 8) {
+		assert.True(t, piece.SignedArea() < 0)
+	}
+	mesh := FilledClockwise.Triangulate(clockwise, TriangulateEarClipping)
+	assert.NotEmpty(t, mesh.Triangles)
+	for _, triangle := range mesh.Triangles {
+		points := Polygon{mesh.Vertices[triangle[0]], mesh.Vertices[triangle[1]], mesh.Vertices[triangle[2]]}
+		assert.True(t, points.SignedArea() < 0)
+	}
+
+	// Results in different conventions can be combined by converting them first
+	square := squarePolygon(0, 0, 2)
+	assert.InDelta(t, -2, totalSignedArea(FilledClockwise.Difference([]Polygon{square.Reverse()}, FilledClockwise.Convert([]Polygon{square.Translate(1, 0)}))), 1e-9)
+
+	sdf := SignedDistanceField(clockwise, image.Rect(0, 0, 10, 8), SDFOptions{Winding: FilledClockwise})
+	assert.Empty(t, FilledClockwise.Validate(TraceSDF(sdf)))
+}